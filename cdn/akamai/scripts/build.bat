@@ -31,7 +31,7 @@ echo [BUILD] windows/amd64 -> akamai_cdn_refresh_windows_amd64.exe
 set GOOS=windows
 set GOARCH=amd64
 set CGO_ENABLED=0
-go build -ldflags "-s -w" -o "dist/akamai_cdn_refresh_windows_amd64.exe" src/akamai_cdn_refresh.go
+go build -ldflags "-s -w" -o "dist/akamai_cdn_refresh_windows_amd64.exe" .\src
 if !errorlevel! equ 0 (
     echo    [OK] win64 build success
     set /a success_count+=1
@@ -44,7 +44,7 @@ echo [BUILD] darwin/amd64 -> akamai_cdn_refresh_darwin_amd64
 set GOOS=darwin
 set GOARCH=amd64
 set CGO_ENABLED=0
-go build -ldflags "-s -w" -o "dist/akamai_cdn_refresh_darwin_amd64" src/akamai_cdn_refresh.go
+go build -ldflags "-s -w" -o "dist/akamai_cdn_refresh_darwin_amd64" .\src
 if !errorlevel! equ 0 (
     echo    [OK] mac64 build success
     set /a success_count+=1
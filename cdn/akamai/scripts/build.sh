@@ -41,7 +41,7 @@ echo "� 开始构建..."
 
 # 构建本地平台版本
 echo "📱 构建本地平台版本..."
-if go build -ldflags="-s -w" -o "$DIST_DIR/akamai_cdn_refresh" akamai_cdn_refresh.go; then
+if go build -ldflags="-s -w" -o "$DIST_DIR/akamai_cdn_refresh" .; then
     echo "✅ 构建成功!"
     
     # 确定可执行文件路径
@@ -73,6 +73,7 @@ if go build -ldflags="-s -w" -o "$DIST_DIR/akamai_cdn_refresh" akamai_cdn_refres
     echo "主配置文件: $DIST_DIR/akamai.conf"
     echo "URL列表: conf/urls.txt"
     echo "CPCode列表: conf/cpcodes.txt"
+    echo "Cache Tag列表: conf/tags.txt"
     echo "查看帮助: $EXEC --help"
     
 else
@@ -275,7 +275,7 @@ for target in "${platforms_to_build[@]}"; do
             ;;
     esac
     
-    env GOOS=$os GOARCH=$arch CGO_ENABLED=0 go build -ldflags="-s -w" -o "$output_path" akamai_cdn_refresh.go
+    env GOOS=$os GOARCH=$arch CGO_ENABLED=0 go build -ldflags="-s -w" -o "$output_path" .
     if [ $? -eq 0 ]; then
         size=$(du -h "$output_path" | cut -f1)
         echo "   [OK] Success: $output_name ($size)"
@@ -371,6 +371,7 @@ All platforms use the same configuration files:
 - `url.json` - URL refresh configuration
 - `urls.txt` - URL list
 - `cpcodes.txt` - CPCode list
+- `tags.txt` - Cache tag list
 
 ## 🎯 Usage Examples
 
@@ -1,16 +1,18 @@
 #!/usr/bin/env bash
 
+# 批量测试不逐个确认，统一加 --yes
+
 # 刷新url  测试脚本
-../dist/akamai_cdn_refresh_windows_amd64.exe https://cdn-mh.hwrescdn.com/test/build_ultra.sh
-../dist/akamai_cdn_refresh_windows_amd64.exe https://cdn-mh.hwrescdn.com/test1/build_ultra.sh
+../dist/akamai_cdn_refresh_windows_amd64.exe --yes https://cdn-mh.hwrescdn.com/test/build_ultra.sh
+../dist/akamai_cdn_refresh_windows_amd64.exe --yes https://cdn-mh.hwrescdn.com/test1/build_ultra.sh
 
 # 刷新目录  测试脚本
-../dist/akamai_cdn_refresh_windows_amd64.exe -d "/test/"
-../dist/akamai_cdn_refresh_windows_amd64.exe -d "/test1/"
+../dist/akamai_cdn_refresh_windows_amd64.exe --yes -d "/test/"
+../dist/akamai_cdn_refresh_windows_amd64.exe --yes -d "/test1/"
 
 # 批量刷新目录  测试脚本
-../dist/akamai_cdn_refresh_windows_amd64.exe -f ../conf/directories.txt
+../dist/akamai_cdn_refresh_windows_amd64.exe --yes -f ../conf/directories.txt
 
 # 批量刷新CP Code  测试脚本
-../dist/akamai_cdn_refresh_windows_amd64.exe -c 1892943
-../dist/akamai_cdn_refresh_windows_amd64.exe -f ../conf/cpcodes.txt
\ No newline at end of file
+../dist/akamai_cdn_refresh_windows_amd64.exe --yes -c 1892943
+../dist/akamai_cdn_refresh_windows_amd64.exe --yes -f ../conf/cpcodes.txt
\ No newline at end of file
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -19,13 +20,25 @@ import (
 
 // AkamaiConfig Akamai配置
 type AkamaiConfig struct {
-	ClientToken  string // Akamai API Client Token
-	ClientSecret string // Akamai API Client Secret
-	AccessToken  string // Akamai API Access Token
-	BaseURL      string // Akamai API基础URL
-	CDNDomain    string // CDN域名，用于目录刷新时构造完整URL
-	Verbose      bool   // 详细模式
-	DryRun       bool   // 预览模式
+	ClientToken   string        // Akamai API Client Token
+	ClientSecret  string        // Akamai API Client Secret
+	AccessToken   string        // Akamai API Access Token
+	BaseURL       string        // Akamai API基础URL
+	CDNDomain     string        // CDN域名，用于目录刷新时构造完整URL
+	RefreshType   string        // 刷新类型: delete 或 remove
+	Network       string        // 刷新的网络: staging 或 production
+	Concurrency   int           // 分批提交时的并发数
+	Retries       int           // 网络错误、5xx、429时的最多重试次数
+	RateLimit     float64       // 每秒最多请求数
+	OriginDomain  string        // 源站域名，验证时与CDN内容对比
+	DirSource     string        // 目录展开的来源，如 local:./dist
+	Verify        bool          // 刷新后探测边缘节点验证
+	VerifyTimeout time.Duration // 验证的截止时间
+	Pragma        bool          // 验证时带上Akamai pragma调试头
+	MaxBody       int           // 签名时计算哈希的请求体最大字节数 (.edgerc max-body)
+	Verbose       bool          // 详细模式
+	AssumeYes     bool          // 跳过确认 (--yes/--force)
+	DryRun        bool          // 预览模式
 }
 
 // AkamaiResponse Akamai API响应
@@ -71,7 +84,7 @@ func normalizePath(path string) string {
 }
 
 // 生成EdgeGrid认证头
-func generateEdgeGridAuth(method, urlStr, body, clientToken, accessToken, clientSecret, host string) string {
+func generateEdgeGridAuth(method, urlStr, body, clientToken, accessToken, clientSecret, host string, maxBody int) string {
 	timestamp := time.Now().UTC().Format("20060102T15:04:05+0000")
 	nonce := generateNonce()
 
@@ -81,7 +94,10 @@ func generateEdgeGridAuth(method, urlStr, body, clientToken, accessToken, client
 		msgPath = fmt.Sprintf("%s?%s", msgPath, parsedURL.RawQuery)
 	}
 
-	// 正确计算content hash
+	// 正确计算content hash，按EdgeGrid规范只计算前max-body字节
+	if maxBody > 0 && len(body) > maxBody {
+		body = body[:maxBody]
+	}
 	hasher := sha256.New()
 	hasher.Write([]byte(body))
 	contentHash := base64.StdEncoding.EncodeToString(hasher.Sum(nil))
@@ -137,6 +153,24 @@ func generateNonce() string {
 		time.Now().UnixNano()&0xffffffffffff)
 }
 
+// CCU v3 接口地址，如 /ccu/v3/invalidate/url/staging
+// remove 对应 v3 的 delete 接口 (直接删除缓存，而不是标记过期)
+func purgeEndpoint(objectType, refreshType, network string) string {
+	action := "invalidate"
+	if refreshType == "remove" {
+		action = "delete"
+	}
+	return fmt.Sprintf("/ccu/v3/%s/%s/%s", action, objectType, network)
+}
+
+// 网络名称，生产网络额外标出
+func networkLabel(network string) string {
+	if network == "production" {
+		return "production (生产网络)"
+	}
+	return "staging (测试网络)"
+}
+
 func hmacSha256(data, key string) string {
 	h := hmac.New(sha256.New, []byte(key))
 	h.Write([]byte(data))
@@ -144,90 +178,78 @@ func hmacSha256(data, key string) string {
 }
 
 // 刷新Akamai CDN缓存 (支持URL和目录路径)
-func refreshAkamaiCDN(config *AkamaiConfig, items []string, refreshType, contentType string) error {
-	if len(items) == 0 {
+func refreshAkamaiCDN(config *AkamaiConfig, items itemGroups, refreshType string) error {
+	if items.count() == 0 {
 		return fmt.Errorf("没有指定要刷新的内容")
 	}
 
-	var requestData map[string]interface{}
-	var apiURL string
-	var endpoint string
-
-	if contentType == "cpcode" {
-		fmt.Printf("🔢 准备刷新 %d 个CPCode\n", len(items))
-		requestData = map[string]interface{}{
-			"objects": items,
-			"type":    refreshType,
-		}
-		endpoint = "/ccu/v3/invalidate/cpcode/production"
-		if refreshType == "remove" {
-			endpoint = "/ccu/v3/remove/cpcode/production"
+	// 每种类型单独构造请求，提交到对应的接口
+	var groups []*purgeGroup
+	for _, contentType := range items.types() {
+		group, err := preparePurgeGroup(config, items[contentType], refreshType, contentType)
+		if err != nil {
+			return err
 		}
-		apiURL = config.BaseURL + endpoint
-	} else {
-		// 规范化所有路径，对于目录路径转换为完整URL
-		normalizedItems := make([]string, len(items))
-		for i, item := range items {
-			normalized := normalizePath(item)
-			if contentType == "directory" && !strings.HasPrefix(normalized, "http") {
-				if config.CDNDomain != "" {
-					normalizedItems[i] = strings.TrimSuffix(config.CDNDomain, "/") + normalized
-				} else {
-					return fmt.Errorf("目录刷新需要在配置文件中设置CDN_DOMAIN")
+		groups = append(groups, group)
+	}
+
+	// 预览模式
+	if config.DryRun {
+		fmt.Printf("🔍 预览模式 - 不会实际执行刷新\n")
+		fmt.Printf("🌍 网络: %s\n", networkLabel(config.Network))
+		for _, group := range groups {
+			if len(groups) > 1 {
+				fmt.Printf("\n━━ %s: %d 个 ━━\n", group.name(), len(group.Objects))
+			}
+			fmt.Printf("📡 API URL: %s\n", group.APIURL)
+			if len(group.Batches) == 1 {
+				fmt.Printf("📋 请求数据: %s\n", string(group.Batches[0].Body))
+				continue
+			}
+			fmt.Printf("📦 共 %d 个对象，分为 %d 批:\n", len(group.Objects), len(group.Batches))
+			for _, batch := range group.Batches {
+				fmt.Printf("  批次 %d/%d: %d 个, %d 字节\n", batch.Index, batch.Total, len(batch.Objects), len(batch.Body))
+				if config.Verbose {
+					fmt.Printf("  📋 请求数据: %s\n", string(batch.Body))
 				}
-			} else {
-				normalizedItems[i] = normalized
 			}
 		}
-		requestData = map[string]interface{}{
-			"objects": normalizedItems,
-			"type":    refreshType,
-		}
-		endpoint = "/ccu/v3/invalidate/url/production"
-		if refreshType == "remove" {
-			endpoint = "/ccu/v3/remove/url/production"
-		}
-		apiURL = config.BaseURL + endpoint
+		return nil
 	}
 
-	jsonData, err := json.Marshal(requestData)
+	// 确认执行
+	confirmed, err := confirmPurge(config, groups, refreshType)
 	if err != nil {
-		return fmt.Errorf("构造请求数据失败: %v", err)
+		return err
+	}
+	if !confirmed {
+		return fmt.Errorf("用户取消操作")
 	}
 
-	// 预览模式
-	if config.DryRun {
-		fmt.Printf("🔍 预览模式 - 不会实际执行刷新\n")
-		fmt.Printf("📡 API URL: %s\n", apiURL)
-		fmt.Printf("📋 请求数据: %s\n", string(jsonData))
-		return nil
+	fmt.Printf("🚀 开始执行Akamai CDN刷新 (网络: %s)...\n", networkLabel(config.Network))
+	if len(groups) == 1 {
+		return submitPurgeGroup(config, groups[0], refreshType)
 	}
 
-	// 确认执行
-	if !config.Verbose {
-		if contentType == "directory" {
-			fmt.Printf("确认执行Akamai CDN目录强制刷新? [y/N]: ")
-		} else {
-			fmt.Printf("确认执行Akamai CDN强制刷新? [y/N]: ")
-		}
-		var confirm string
-		fmt.Scanln(&confirm)
-		if strings.ToLower(confirm) != "y" && strings.ToLower(confirm) != "yes" {
-			fmt.Printf("❌ 用户取消操作\n")
-			return nil
-		}
+	// 一组失败不影响其他组提交
+	for _, group := range groups {
+		fmt.Printf("\n━━ %s: %d 个 ━━\n", group.name(), len(group.Objects))
+		group.Err = submitPurgeGroup(config, group, refreshType)
 	}
+	return printGroupSummary(groups)
+}
 
-	fmt.Printf("🚀 开始执行Akamai CDN刷新...\n")
+// 提交一批刷新请求
+func sendPurgeRequest(config *AkamaiConfig, apiURL string, batch purgeBatch) (*AkamaiResponse, error) {
+	label := batch.label()
 	if config.Verbose {
-		fmt.Printf("📡 API URL: %s\n", apiURL)
-		fmt.Printf("📋 请求数据: %s\n", string(jsonData))
+		fmt.Printf("📋 %s请求数据: %s\n", label, string(batch.Body))
 	}
 
 	// 创建HTTP请求
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(batch.Body))
 	if err != nil {
-		return fmt.Errorf("创建请求失败: %v", err)
+		return nil, fmt.Errorf("创建请求失败: %v", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -235,172 +257,85 @@ func refreshAkamaiCDN(config *AkamaiConfig, items []string, refreshType, content
 
 	baseURL, err := url.Parse(config.BaseURL)
 	if err != nil {
-		return fmt.Errorf("解析Akamai Base URL失败: %v", err)
+		return nil, fmt.Errorf("解析Akamai Base URL失败: %v", err)
 	}
 
 	req.Header.Set("Host", baseURL.Host)
 
-	authHeader := generateEdgeGridAuth(req.Method, apiURL, string(jsonData),
-		config.ClientToken, config.AccessToken, config.ClientSecret, baseURL.Host)
+	authHeader := generateEdgeGridAuth(req.Method, apiURL, string(batch.Body),
+		config.ClientToken, config.AccessToken, config.ClientSecret, baseURL.Host, config.MaxBody)
 	req.Header.Set("Authorization", authHeader)
 
 	if config.Verbose {
-		fmt.Printf("🔐 Authorization: %s\n", authHeader)
+		fmt.Printf("🔐 %sAuthorization: %s\n", label, authHeader)
 	}
 
 	client := &http.Client{Timeout: 30 * time.Second}
 
-	fmt.Printf("📤 发送刷新请求...\n")
+	fmt.Printf("📤 %s发送刷新请求 (%d 个)...\n", label, len(batch.Objects))
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("请求失败: %v", err)
+		return nil, &retryableError{Err: fmt.Errorf("请求失败: %v", err)}
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("读取响应失败: %v", err)
+		return nil, &retryableError{Err: fmt.Errorf("读取响应失败: %v", err)}
 	}
 
 	if config.Verbose {
-		fmt.Printf("📥 HTTP状态: %d\n", resp.StatusCode)
-		fmt.Printf("📥 响应内容: %s\n", string(body))
+		fmt.Printf("📥 %sHTTP状态: %d\n", label, resp.StatusCode)
+		fmt.Printf("📥 %s响应内容: %s\n", label, string(body))
 	}
 
 	// 处理响应
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		var response AkamaiResponse
 		if err := json.Unmarshal(body, &response); err != nil {
-			fmt.Printf("⚠️  解析响应失败，但请求可能成功: %v\n", err)
-		} else {
-			fmt.Printf("✅ Akamai CDN刷新任务提交成功!\n")
-			if response.PurgeID != "" {
-				fmt.Printf("🆔 任务ID: %s\n", response.PurgeID)
-			}
-			if response.EstimatedSeconds > 0 {
-				fmt.Printf("⏰ 预计生效时间: %d 秒\n", response.EstimatedSeconds)
-			} else {
-				fmt.Printf("⏰ 预计生效时间: 5-10 分钟\n")
-			}
-
-			fmt.Printf("\n📋 已提交刷新的内容:\n")
-			for _, item := range items {
-				fmt.Printf("  ✓ %s\n", item)
-			}
-
-			// 新增简明日志
-			fmt.Printf("[刷新成功] 类型: %s, 内容: %s, 数量: %d, 任务ID: %s\n", refreshType, contentType, len(items), response.PurgeID)
+			fmt.Printf("⚠️  %s解析响应失败，但请求可能成功: %v\n", label, err)
 		}
+		return &response, nil
 	} else if resp.StatusCode == 401 && strings.Contains(string(body), "Inactive client token") {
-		fmt.Printf("❌ API Client未激活\n")
-		fmt.Printf("\n🔑 您的API Client Token: %s\n", config.ClientToken)
-		fmt.Printf("📊 状态: INACTIVE (需要激活)\n")
-		fmt.Printf("\n🎯 激活步骤:\n")
-		fmt.Printf("1. 访问: https://control.akamai.com/\n")
-		fmt.Printf("2. 登录您的Akamai账户\n")
-		fmt.Printf("3. 导航到: Identity & Access Management > API Clients\n")
-		fmt.Printf("4. 搜索: %s\n", config.ClientToken)
-		fmt.Printf("5. 点击进入详情页面\n")
-		fmt.Printf("6. 点击 'Activate' 或 'Enable' 按钮\n")
-		fmt.Printf("7. 确认包含 CCU (Content Control Utility) 权限\n")
-		fmt.Printf("8. 保存更改\n")
-		fmt.Printf("\n🧪 激活后验证:\n")
-		fmt.Printf("./akamai_cdn_refresh_dir.exe --force %s\n", items[0])
-		fmt.Printf("\n💡 技术分析: 工具本身运行正常，仅需激活API Client\n")
-		return fmt.Errorf("API Client未激活，请按上述步骤激活后重试")
-	} else {
-		return fmt.Errorf("刷新失败: HTTP %d - %s", resp.StatusCode, string(body))
-	}
-
-	return nil
-}
-
-// 加载配置文件
-func loadAkamaiConfigFromFile() (*AkamaiConfig, error) {
-	// 优先尝试 ../conf/akamai.conf，其次 ./akamai.conf
-	var configPath string
-	if _, err := os.Stat("../conf/akamai.conf"); err == nil {
-		configPath = "../conf/akamai.conf"
-	} else if _, err := os.Stat("conf/akamai.conf"); err == nil {
-		configPath = "conf/akamai.conf"
-	} else if _, err := os.Stat("./akamai.conf"); err == nil {
-		configPath = "./akamai.conf"
-	} else {
-		return nil, fmt.Errorf("找不到配置文件（../conf/akamai.conf、conf/akamai.conf、./akamai.conf 均不存在）")
-	}
-
-	content, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %v", err)
-	}
-
-	config := &AkamaiConfig{}
-	lines := strings.Split(string(content), "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		if strings.Contains(line, "=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) != 2 {
-				continue
-			}
-
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-
-			// 移除引号
-			if len(value) >= 2 && ((value[0] == '"' && value[len(value)-1] == '"') ||
-				(value[0] == '\'' && value[len(value)-1] == '\'')) {
-				value = value[1 : len(value)-1]
-			}
-
-			switch key {
-			case "AKAMAI_CLIENT_TOKEN":
-				config.ClientToken = value
-			case "AKAMAI_CLIENT_SECRET":
-				config.ClientSecret = value
-			case "AKAMAI_ACCESS_TOKEN":
-				config.AccessToken = value
-			case "AKAMAI_BASE_URL":
-				config.BaseURL = value
-			case "CDN_DOMAIN":
-				config.CDNDomain = value
-			}
+		return nil, errClientInactive
+	} else if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, &retryableError{
+			Err:        fmt.Errorf("刷新失败: HTTP %d - %s", resp.StatusCode, string(body)),
+			RetryAfter: retryAfterFromHeader(resp.Header),
 		}
 	}
+	return nil, fmt.Errorf("刷新失败: HTTP %d - %s", resp.StatusCode, string(body))
+}
 
-	// 验证必需的配置
-	if config.ClientToken == "" {
-		return nil, fmt.Errorf("配置文件中缺少AKAMAI_CLIENT_TOKEN")
-	}
-	if config.ClientSecret == "" {
-		return nil, fmt.Errorf("配置文件中缺少AKAMAI_CLIENT_SECRET")
-	}
-	if config.AccessToken == "" {
-		return nil, fmt.Errorf("配置文件中缺少AKAMAI_ACCESS_TOKEN")
-	}
-	if config.BaseURL == "" {
-		return nil, fmt.Errorf("配置文件中缺少AKAMAI_BASE_URL")
-	}
-
-	fmt.Printf("✅ 已从配置文件加载Akamai配置: %s\n", configPath)
-	return config, nil
+var errClientInactive = errors.New("API Client未激活，请按上述步骤激活后重试")
+
+func printInactiveClientHelp(config *AkamaiConfig, item string) {
+	fmt.Printf("❌ API Client未激活\n")
+	fmt.Printf("\n🔑 您的API Client Token: %s\n", config.ClientToken)
+	fmt.Printf("📊 状态: INACTIVE (需要激活)\n")
+	fmt.Printf("\n🎯 激活步骤:\n")
+	fmt.Printf("1. 访问: https://control.akamai.com/\n")
+	fmt.Printf("2. 登录您的Akamai账户\n")
+	fmt.Printf("3. 导航到: Identity & Access Management > API Clients\n")
+	fmt.Printf("4. 搜索: %s\n", config.ClientToken)
+	fmt.Printf("5. 点击进入详情页面\n")
+	fmt.Printf("6. 点击 'Activate' 或 'Enable' 按钮\n")
+	fmt.Printf("7. 确认包含 CCU (Content Control Utility) 权限\n")
+	fmt.Printf("8. 保存更改\n")
+	fmt.Printf("\n🧪 激活后验证:\n")
+	fmt.Printf("./akamai_cdn_refresh_dir.exe --force %s\n", item)
+	fmt.Printf("\n💡 技术分析: 工具本身运行正常，仅需激活API Client\n")
 }
 
-// 从文件读取URL列表或目录路径列表
-func loadItemsFromFile(filename string) ([]string, string, error) {
+// 从文件读取刷新内容，每行单独判断类型，可以混合URL、目录、CPCode和Cache Tag
+func loadItemsFromFile(filename string) (itemGroups, error) {
 	content, err := os.ReadFile(filename)
 	if err != nil {
-		return nil, "", fmt.Errorf("读取文件失败: %v", err)
+		return nil, fmt.Errorf("读取文件失败: %v", err)
 	}
 
 	lines := strings.Split(string(content), "\n")
-	var items []string
-	contentType := "url"
+	items := itemGroups{}
 
 	for i, line := range lines {
 		line = strings.TrimSpace(line)
@@ -408,21 +343,14 @@ func loadItemsFromFile(filename string) ([]string, string, error) {
 			continue
 		}
 
-		// 检测内容类型
-		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
-			contentType = "url"
-		} else if strings.HasPrefix(line, "/") {
-			contentType = "directory"
-		} else if _, err := strconv.Atoi(line); err == nil {
-			contentType = "cpcode"
-		} else {
-			return nil, "", fmt.Errorf("第%d行格式错误: %s (应该是URL、以/开头的目录路径或纯数字CPCode)", i+1, line)
+		contentType, item, err := classifyItem(line)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: %v", i+1, err)
 		}
-
-		items = append(items, line)
+		items.add(contentType, item)
 	}
 
-	return items, contentType, nil
+	return items, nil
 }
 
 // 显示使用帮助
@@ -434,28 +362,66 @@ func showUsage() {
   %s [选项] -f <文件路径>
   %s [选项] -d <目录路径1> [目录路径2] ...
   %s [选项] -c <CPCode1> [CPCode2] ...
+  %s [选项] -g <Tag1> [Tag2] ...
 
 选项:
   -h, --help     显示此帮助信息
   -v, --verbose  详细模式，显示详细的API调用信息
   -n, --dry-run  预览模式，不实际执行刷新
-  -f, --file     从文件读取URL或目录路径列表
-  -d, --dir      刷新目录路径 (目录刷新)
+  -f, --file     从文件读取刷新内容，每行单独判断类型，可与命令行内容合并
+  -d, --dir      刷新目录路径 (目录刷新)，配合 --source 展开为目录下的所有URL
   -c, --cpcode   按CPCode刷新 (支持多个)
-  -t, --type     刷新类型: delete (默认，invalidate) 或 remove (purge)
-  --force        强制执行，跳过确认提示
+  -g, --tag      按Cache Tag刷新 (支持多个，对应源站的Edge-Cache-Tag响应头)
+                 纯数字会被当作CPCode，纯数字的tag写成 tag:2024
+  -t, --type     刷新类型: delete (invalidate) 或 remove (purge)，默认取DEFAULT_REFRESH_TYPE
+  -y, --yes      跳过确认提示直接执行 (同 --force)；非交互环境 (CI、管道) 必须指定
+  --force        同 --yes
+  --concurrency N  内容超过单次请求上限 (50,000字节) 自动分批时，同时提交的批次数，默认2
+  --retries N      网络错误、5xx、429时最多重试次数，默认4 (指数退避，遵循Retry-After)
+  --rate-limit N   每秒最多发送的请求数，默认10
+  --source SPEC    目录展开的来源，覆盖DIR_SOURCE:
+                     local:./dist           本地构建目录，对应CDN_DOMAIN的根目录
+                     oss://bucket/前缀      OSS中的对象 (使用OSS_ENDPOINT等环境变量)
+                     sitemap:FILE|URL       sitemap.xml
+                     manifest:FILE          URL/路径列表，或OSS上传工具的JSON清单
+  --max-urls N     目录展开后的URL数量上限，默认10000
+  --fallback SPEC  超过上限时改为 cpcode:CODE 或 tag:TAG 刷新，不指定时报错
+  --verify         提交成功后等待预计生效时间，探测边缘节点确认已是新内容
+  --verify-timeout 验证的截止时间，从提交刷新开始计算，默认10m
+  --origin URL     验证时对比的源站域名，覆盖ORIGIN_DOMAIN
+  --pragma         验证时带上Akamai pragma调试头 (X-Cache等)
+  --network NAME 刷新的网络: staging 或 production，默认取AKAMAI_NETWORK (production)
+  --config FILE  配置文件 (KEY="value" 格式，同akamai.conf)
+  --edgerc FILE  读取.edgerc格式的凭证，默认~/.edgerc (或环境变量AKAMAI_EDGERC)
+  --section NAME .edgerc中的section，默认default (或环境变量AKAMAI_EDGERC_SECTION)
+  --base-url URL     覆盖AKAMAI_BASE_URL
+  --cdn-domain URL   覆盖CDN_DOMAIN
 
 刷新类型说明:
   URL刷新        刷新指定的具体文件URL
   目录刷新       刷新指定目录路径下的所有内容
   CPCode刷新     按指定CPCode刷新全站内容
+  Tag刷新        刷新带有指定Edge-Cache-Tag的所有内容 (如某个游戏版本的全部资源)
+  混合刷新       不加 -d/-c/-g 时按格式判断: http(s)://开头为URL，/开头为目录，
+                 纯数字为CPCode，tag:开头为Cache Tag；不同类型分组提交到各自的接口
 
-环境变量:
-  配置文件: akamai.conf
+配置 (环境变量和配置文件使用相同的名称):
   AKAMAI_CLIENT_TOKEN    Akamai API Client Token
-  AKAMAI_CLIENT_SECRET   Akamai API Client Secret  
+  AKAMAI_CLIENT_SECRET   Akamai API Client Secret
   AKAMAI_ACCESS_TOKEN    Akamai API Access Token
   AKAMAI_BASE_URL        Akamai API Base URL
+  DEFAULT_REFRESH_TYPE   默认刷新类型: delete 或 remove
+  CDN_DOMAIN             目录刷新使用的CDN域名
+  AKAMAI_NETWORK         默认刷新的网络: staging 或 production
+  ORIGIN_DOMAIN          源站域名，--verify时比较ETag/Last-Modified/内容
+  DIR_SOURCE             目录展开的默认来源，同 --source
+  AKAMAI_MAX_BODY        签名时计算哈希的请求体最大字节数，默认131072
+
+  优先级: 命令行 > 环境变量 > --config、--edgerc/--section
+          > $XDG_CONFIG_HOME/akamai/akamai.conf 或 ~/.akamai.conf > ~/.edgerc [default]
+          > ../conf/akamai.conf、conf/akamai.conf、./akamai.conf
+  .edgerc 中的client_token、client_secret、access_token、host、max-body 对应上面的配置项
+  %s config show   查看每个配置项的最终值和来源 (密钥已隐藏)
 
 示例:
   # 刷新单个URL
@@ -470,6 +436,9 @@ func showUsage() {
   # 刷新多个目录
   %s -d /static/css/ /static/js/
 
+  # 按本地构建目录把目录展开为具体URL，超过上限时改为按tag刷新
+  %s --source local:./dist --fallback tag:game-v1.2.0 -d /static/
+
   # 从文件批量刷新URL
   %s -f urls.txt
 
@@ -482,10 +451,23 @@ func showUsage() {
   # 从文件批量刷新CPCode
   %s -f cpcodes.txt
 
+  # 按Cache Tag刷新 (文件中每行写 tag:名称)
+  %s -g game-v1.2.0 game-v1.2.0-cfg
+  %s -f tags.txt
+
+  # 一次刷新多种内容 (文件中也可以混合)，按类型分组提交
+  %s https://cdn-mh.hwrescdn.com/test.css /static/js/ 1892943 tag:game-v1.2.0
+
   # 预览模式 (不实际执行)
   %s -n https://cdn-mh.hwrescdn.com/test.css
 
-  # 强制执行 (跳过确认)
+  # 先在staging网络上刷新，验证后再刷新production
+  %s --network staging https://cdn-mh.hwrescdn.com/test.css
+
+  # 刷新后验证边缘节点已返回源站的新内容
+  %s --verify --origin https://origin.example.com https://cdn-mh.hwrescdn.com/test.css
+
+  # 强制执行 (跳过确认，CI中使用)
   %s --force https://cdn-mh.hwrescdn.com/test.css
 
 优势:
@@ -499,7 +481,7 @@ func showUsage() {
   📌 目录路径会自动规范化为Unix风格 (/static/css/)
   📌 支持Windows和Unix路径格式输入
   📌 路径参数建议使用双引号包围: -d "/static/css/"
-`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
 }
 
 func main() {
@@ -511,13 +493,33 @@ func main() {
 	}
 
 	var (
-		items       []string
+		items       = itemGroups{}
 		dryRun      bool
-		refreshType = "delete"
+		verbose     bool
+		assumeYes   bool
 		filename    string
-		contentType = "url"
+		concurrency = defaultPurgeConcurrency
+		retries     = defaultRetries
+		rateLimit   = float64(defaultRateLimit)
+		verify      bool
+		pragma      bool
+		verifyWait  = defaultVerifyTimeout
+		maxURLs     = defaultMaxURLs
+		fallback    string
+		emptyHint   = "url" // 没有指定内容时，按最后使用的类型参数提示
+		options     = configOptions{Flags: map[string]string{}, FlagNames: map[string]string{}}
 	)
 
+	// 命令行参数覆盖的配置项
+	setFlag := func(key, flag, value string) {
+		options.Flags[key] = value
+		options.FlagNames[key] = flag
+	}
+	configShow := len(args) >= 2 && args[0] == "config" && args[1] == "show"
+	if configShow {
+		args = args[2:]
+	}
+
 	// 解析参数
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
@@ -527,23 +529,135 @@ func main() {
 			os.Exit(0)
 		case "-n", "--dry-run": // 预览模式
 			dryRun = true
+		case "-v", "--verbose":
+			verbose = true
+		case "-y", "--yes", "--force":
+			assumeYes = true
 		case "-t", "--type":
 			if i+1 >= len(args) {
 				fmt.Printf("❌ 错误: -t/--type 需要指定刷新类型\n")
 				os.Exit(1)
 			}
-			refreshType = args[i+1]
-			if refreshType != "delete" && refreshType != "remove" {
+			if args[i+1] != "delete" && args[i+1] != "remove" {
 				fmt.Printf("❌ 错误: 刷新类型必须是 delete 或 remove\n")
 				os.Exit(1)
 			}
+			setFlag("DEFAULT_REFRESH_TYPE", arg, args[i+1])
+			i++
+		case "--concurrency":
+			if i+1 >= len(args) {
+				fmt.Printf("❌ 错误: --concurrency 需要指定并发数\n")
+				os.Exit(1)
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				fmt.Printf("❌ 错误: 并发数必须是正整数: %s\n", args[i+1])
+				os.Exit(1)
+			}
+			concurrency = n
+			i++
+		case "--max-urls":
+			if i+1 >= len(args) {
+				fmt.Printf("❌ 错误: --max-urls 需要指定数量\n")
+				os.Exit(1)
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				fmt.Printf("❌ 错误: --max-urls 必须是正整数: %s\n", args[i+1])
+				os.Exit(1)
+			}
+			maxURLs = n
+			i++
+		case "--fallback":
+			if i+1 >= len(args) {
+				fmt.Printf("❌ 错误: --fallback 需要指定 cpcode:CODE 或 tag:TAG\n")
+				os.Exit(1)
+			}
+			if err := validateFallback(args[i+1]); err != nil {
+				fmt.Printf("❌ 错误: %v\n", err)
+				os.Exit(1)
+			}
+			fallback = args[i+1]
+			i++
+		case "--verify":
+			verify = true
+		case "--pragma":
+			pragma = true
+		case "--verify-timeout":
+			if i+1 >= len(args) {
+				fmt.Printf("❌ 错误: --verify-timeout 需要指定时间，如 10m\n")
+				os.Exit(1)
+			}
+			d, err := time.ParseDuration(args[i+1])
+			if err != nil || d <= 0 {
+				fmt.Printf("❌ 错误: 无效的验证时间: %s (如 90s、10m)\n", args[i+1])
+				os.Exit(1)
+			}
+			verifyWait = d
+			i++
+		case "--retries", "--rate-limit":
+			if i+1 >= len(args) {
+				fmt.Printf("❌ 错误: %s 需要参数\n", arg)
+				os.Exit(1)
+			}
+			if arg == "--retries" {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 0 {
+					fmt.Printf("❌ 错误: 重试次数必须是非负整数: %s\n", args[i+1])
+					os.Exit(1)
+				}
+				retries = n
+			} else {
+				n, err := strconv.ParseFloat(args[i+1], 64)
+				if err != nil || n <= 0 {
+					fmt.Printf("❌ 错误: 每秒请求数必须大于0: %s\n", args[i+1])
+					os.Exit(1)
+				}
+				rateLimit = n
+			}
+			i++
+		case "--network":
+			if i+1 >= len(args) {
+				fmt.Printf("❌ 错误: --network 需要指定网络\n")
+				os.Exit(1)
+			}
+			if args[i+1] != "staging" && args[i+1] != "production" {
+				fmt.Printf("❌ 错误: 网络必须是 staging 或 production\n")
+				os.Exit(1)
+			}
+			setFlag("AKAMAI_NETWORK", arg, args[i+1])
+			i++
+		case "--config", "--base-url", "--cdn-domain", "--origin", "--source", "--edgerc", "--section":
+			if i+1 >= len(args) {
+				fmt.Printf("❌ 错误: %s 需要参数\n", arg)
+				os.Exit(1)
+			}
+			switch arg {
+			case "--config":
+				options.ConfigFile = args[i+1]
+			case "--edgerc":
+				options.EdgercFile = args[i+1]
+			case "--section":
+				options.Section = args[i+1]
+			case "--base-url":
+				setFlag("AKAMAI_BASE_URL", arg, args[i+1])
+			case "--cdn-domain":
+				setFlag("CDN_DOMAIN", arg, args[i+1])
+			case "--origin":
+				setFlag("ORIGIN_DOMAIN", arg, args[i+1])
+			case "--source":
+				setFlag("DIR_SOURCE", arg, args[i+1])
+			}
 			i++
 		case "-d", "--dir":
-			contentType = "directory"
-			// 后续的参数都被当作目录路径处理
+			emptyHint = "directory"
+			// 后续参数是目录路径，遇到URL、CPCode或Cache Tag为止
 			for i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				if contentType, _, err := classifyItem(args[i+1]); err == nil && contentType != "directory" {
+					break
+				}
 				i++
-				items = append(items, args[i])
+				items.add("directory", args[i])
 			}
 		case "-f", "--file":
 			if i+1 >= len(args) {
@@ -553,56 +667,71 @@ func main() {
 			filename = args[i+1]
 			i++
 		case "-c", "--cpcode":
-			contentType = "cpcode"
-			items = nil // 清空items，避免混入其他参数
+			emptyHint = "cpcode"
+			// 后续的纯数字参数是CPCode，其他参数按格式单独判断
 			for i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
 				next := args[i+1]
 				if _, err := strconv.Atoi(next); err == nil {
 					i++
-					items = append(items, next)
+					items.add("cpcode", next)
 				} else {
 					break
 				}
 			}
+		case "-g", "--tag":
+			emptyHint = "tag"
+			// 后续参数是Cache Tag，遇到URL、目录路径或纯数字 (CPCode) 为止
+			// 纯数字的tag需要写成 tag:2024
+			for i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				if contentType, _, err := classifyItem(args[i+1]); err == nil && contentType != "tag" {
+					break
+				}
+				i++
+				items.add("tag", strings.TrimPrefix(args[i], tagFilePrefix))
+			}
 		default:
-			// 跳过所有以-开头的参数（如-c、-d、-f等）
 			if strings.HasPrefix(arg, "-") {
-				continue
+				fmt.Printf("❌ 错误: 未知选项 %s (%s --help 查看帮助)\n", arg, os.Args[0])
+				os.Exit(1)
 			}
-			// 判断是否是URL或目录路径
-			if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") {
-				items = append(items, arg)
-			} else if strings.HasPrefix(arg, "/") {
-				// 以 / 开头的认为是目录路径
-				contentType = "directory"
-				items = append(items, arg)
-			} else {
-				items = append(items, arg)
+			// 按格式判断是URL、目录路径、CPCode还是Cache Tag
+			contentType, item, err := classifyItem(arg)
+			if err != nil {
+				fmt.Printf("❌ 错误: %v\n", err)
+				os.Exit(1)
 			}
+			items.add(contentType, item)
 		}
 	}
 
-	// 从文件读取URL/目录路径
-	if filename != "" {
-		if len(items) > 0 {
-			fmt.Printf("❌ 错误: 不能同时指定内容和文件\n")
+	if configShow {
+		if err := runConfigShow(options); err != nil {
+			fmt.Printf("❌ 配置错误: %v\n", err)
 			os.Exit(1)
 		}
-		fileItems, detectedType, err := loadItemsFromFile(filename)
+		return
+	}
+
+	// 从文件读取刷新内容，与命令行指定的内容合并
+	if filename != "" {
+		fileItems, err := loadItemsFromFile(filename)
 		if err != nil {
 			fmt.Printf("❌ 错误: %v\n", err)
 			os.Exit(1)
 		}
-		items = fileItems
-		contentType = detectedType
+		for _, contentType := range fileItems.types() {
+			items.add(contentType, fileItems[contentType]...)
+		}
 	}
 
 	// 验证有内容需要刷新
-	if len(items) == 0 {
-		if contentType == "directory" {
+	if items.count() == 0 {
+		if emptyHint == "directory" {
 			fmt.Printf("❌ 错误: 请指定要刷新的目录路径或使用 -f 指定文件\n")
-		} else if contentType == "cpcode" {
+		} else if emptyHint == "cpcode" {
 			fmt.Printf("❌ 错误: 请指定要刷新的CPCode或使用 -f 指定文件\n")
+		} else if emptyHint == "tag" {
+			fmt.Printf("❌ 错误: 请指定要刷新的Cache Tag或使用 -f 指定文件\n")
 		} else {
 			fmt.Printf("❌ 错误: 请指定要刷新的URL或使用 -f 指定文件\n")
 		}
@@ -611,10 +740,10 @@ func main() {
 	}
 
 	// 加载配置
-	config, err := loadAkamaiConfigFromFile()
+	config, err := loadAkamaiConfig(options)
 	if err != nil {
 		fmt.Printf("❌ 配置错误: %v\n", err)
-		fmt.Printf("\n💡 提示: 请创建akamai.conf文件:\n")
+		fmt.Printf("\n💡 提示: 设置环境变量、使用~/.edgerc (--edgerc/--section)，或创建akamai.conf文件 (%s config show 查看查找位置):\n", os.Args[0])
 		fmt.Printf("  AKAMAI_CLIENT_TOKEN=\"your-client-token\"\n")
 		fmt.Printf("  AKAMAI_CLIENT_SECRET=\"your-client-secret\"\n")
 		fmt.Printf("  AKAMAI_ACCESS_TOKEN=\"your-access-token\"\n")
@@ -622,11 +751,33 @@ func main() {
 		os.Exit(1)
 	}
 
-	config.Verbose = true // 始终详细输出，且跳过交互
+	config.Verbose = verbose
+	config.AssumeYes = assumeYes
 	config.DryRun = dryRun
+	config.Concurrency = concurrency
+	config.Retries = retries
+	config.RateLimit = rateLimit
+	config.Verify = verify
+	config.VerifyTimeout = verifyWait
+	config.Pragma = pragma
+
+	// 目录刷新展开为目录下的具体URL，并入对应类型的组
+	if dirs := items["directory"]; len(dirs) > 0 {
+		if config.DirSource == "" {
+			fmt.Printf("⚠️  目录URL只会刷新目录本身，不会刷新目录下的文件；可用 --source 展开为具体URL\n")
+		} else {
+			expanded, contentType, err := expandDirectories(config, dirs, maxURLs, fallback)
+			if err != nil {
+				fmt.Printf("❌ 错误: %v\n", err)
+				os.Exit(1)
+			}
+			delete(items, "directory")
+			items.add(contentType, expanded...)
+		}
+	}
 
 	// 调用刷新逻辑
-	if err := refreshAkamaiCDN(config, items, refreshType, contentType); err != nil {
+	if err := refreshAkamaiCDN(config, items, config.RefreshType); err != nil {
 		fmt.Printf("❌ 刷新失败: %v\n", err)
 		os.Exit(1)
 	}
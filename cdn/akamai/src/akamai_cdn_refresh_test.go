@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestPurgeEndpoint(t *testing.T) {
+	tests := []struct {
+		objectType  string
+		refreshType string
+		network     string
+		want        string
+	}{
+		{"url", "delete", "production", "/ccu/v3/invalidate/url/production"},
+		{"url", "remove", "production", "/ccu/v3/delete/url/production"},
+		{"url", "delete", "staging", "/ccu/v3/invalidate/url/staging"},
+		{"cpcode", "remove", "staging", "/ccu/v3/delete/cpcode/staging"},
+		{"tag", "delete", "staging", "/ccu/v3/invalidate/tag/staging"},
+	}
+	for _, tt := range tests {
+		if got := purgeEndpoint(tt.objectType, tt.refreshType, tt.network); got != tt.want {
+			t.Errorf("purgeEndpoint(%q, %q, %q) = %q, want %q", tt.objectType, tt.refreshType, tt.network, got, tt.want)
+		}
+	}
+}
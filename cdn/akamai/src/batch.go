@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxPurgeBodyBytes       = 50000 // Fast Purge 请求体最大50,000字节
+	defaultPurgeConcurrency = 2     // 同时提交的批次数
+	maxListedItems          = 20    // 结果中最多列出的对象数
+)
+
+// 一批刷新请求
+type purgeBatch struct {
+	Index   int // 从1开始
+	Total   int
+	Objects []string
+	Body    []byte
+}
+
+func (b purgeBatch) label() string {
+	if b.Total <= 1 {
+		return ""
+	}
+	return fmt.Sprintf("[批次 %d/%d] ", b.Index, b.Total)
+}
+
+// 一批的提交结果
+type batchResult struct {
+	Batch    purgeBatch
+	Response *AkamaiResponse
+	Attempts int
+	Err      error
+}
+
+// 按请求体大小 (和对象数量，maxObjects为0时不限制) 把对象分批，fields为objects以外的字段
+func splitPurgeBatches(objects []string, fields map[string]interface{}, maxObjects int) ([]purgeBatch, error) {
+	build := func(batchObjects []string) ([]byte, error) {
+		data := map[string]interface{}{"objects": batchObjects}
+		for key, value := range fields {
+			data[key] = value
+		}
+		body, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("构造请求数据失败: %v", err)
+		}
+		return body, nil
+	}
+
+	empty, err := build([]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	var batches []purgeBatch
+	var current []string
+	size := len(empty)
+	flush := func() error {
+		if len(current) == 0 {
+			return nil
+		}
+		body, err := build(current)
+		if err != nil {
+			return err
+		}
+		batches = append(batches, purgeBatch{Index: len(batches) + 1, Objects: current, Body: body})
+		current, size = nil, len(empty)
+		return nil
+	}
+
+	for _, object := range objects {
+		encoded, err := json.Marshal(object)
+		if err != nil {
+			return nil, fmt.Errorf("构造请求数据失败: %v", err)
+		}
+		if len(empty)+len(encoded) > maxPurgeBodyBytes {
+			return nil, fmt.Errorf("单个对象超过请求体上限%d字节: %.80s...", maxPurgeBodyBytes, object)
+		}
+		added := len(encoded)
+		if len(current) > 0 {
+			added++ // 分隔的逗号
+		}
+		if size+added > maxPurgeBodyBytes || (maxObjects > 0 && len(current) >= maxObjects) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			added = len(encoded)
+		}
+		current = append(current, object)
+		size += added
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	for i := range batches {
+		batches[i].Total = len(batches)
+	}
+	return batches, nil
+}
+
+// 以有限的并发提交所有批次，结果顺序与批次一致
+func submitPurgeBatches(config *AkamaiConfig, apiURL string, batches []purgeBatch) []batchResult {
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultPurgeConcurrency
+	}
+
+	limiter := newTokenBucket(config.RateLimit, rateLimitBurst)
+	results := make([]batchResult, len(batches))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, batch purgeBatch) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			response, attempts, err := sendPurgeRequestWithRetry(config, apiURL, batch, limiter)
+			results[i] = batchResult{Batch: batch, Response: response, Attempts: attempts, Err: err}
+		}(i, batch)
+	}
+	wg.Wait()
+	return results
+}
+
+// 汇总所有批次的任务ID和预计时间，失败的批次写入文件以便单独重试
+func reportPurgeResults(config *AkamaiConfig, results []batchResult, refreshType, contentType string) error {
+	var purgeIDs []string
+	var succeeded []string
+	var failed []batchResult
+	maxSeconds := 0
+	retried := 0
+	for _, result := range results {
+		if result.Attempts > 1 {
+			retried++
+		}
+		if result.Err != nil {
+			failed = append(failed, result)
+			continue
+		}
+		succeeded = append(succeeded, result.Batch.Objects...)
+		if result.Response.PurgeID != "" {
+			purgeIDs = append(purgeIDs, result.Response.PurgeID)
+		}
+		if result.Response.EstimatedSeconds > maxSeconds {
+			maxSeconds = result.Response.EstimatedSeconds
+		}
+	}
+
+	if len(succeeded) > 0 {
+		if len(failed) == 0 {
+			fmt.Printf("✅ Akamai CDN刷新任务提交成功!\n")
+		} else {
+			fmt.Printf("⚠️  部分批次提交成功: %d/%d\n", len(results)-len(failed), len(results))
+		}
+		fmt.Printf("🌍 网络: %s\n", networkLabel(config.Network))
+		if len(results) == 1 {
+			if len(purgeIDs) > 0 {
+				fmt.Printf("🆔 任务ID: %s\n", purgeIDs[0])
+			}
+		} else {
+			fmt.Printf("🆔 任务ID:\n")
+			for _, result := range results {
+				if result.Err == nil {
+					attempts := ""
+					if result.Attempts > 1 {
+						attempts = fmt.Sprintf(", 尝试 %d 次", result.Attempts)
+					}
+					fmt.Printf("  批次 %d/%d: %s (%d 个, 预计 %d 秒%s)\n", result.Batch.Index, result.Batch.Total,
+						result.Response.PurgeID, len(result.Batch.Objects), result.Response.EstimatedSeconds, attempts)
+				}
+			}
+		}
+		if len(results) == 1 && results[0].Attempts > 1 {
+			fmt.Printf("🔁 共尝试 %d 次\n", results[0].Attempts)
+		} else if retried > 0 {
+			fmt.Printf("🔁 重试: %d 个批次经过重试\n", retried)
+		}
+		if maxSeconds > 0 {
+			fmt.Printf("⏰ 预计生效时间: %d 秒\n", maxSeconds)
+		} else {
+			fmt.Printf("⏰ 预计生效时间: 5-10 分钟\n")
+		}
+
+		fmt.Printf("\n📋 已提交刷新的内容:\n")
+		for i, item := range succeeded {
+			if i == maxListedItems {
+				fmt.Printf("  ... 还有 %d 个\n", len(succeeded)-maxListedItems)
+				break
+			}
+			fmt.Printf("  ✓ %s\n", item)
+		}
+
+		// 新增简明日志
+		fmt.Printf("[刷新成功] 网络: %s, 类型: %s, 内容: %s, 数量: %d, 任务ID: %s\n",
+			config.Network, refreshType, contentType, len(succeeded), strings.Join(purgeIDs, ","))
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	if len(results) == 1 {
+		if errors.Is(failed[0].Err, errClientInactive) {
+			printInactiveClientHelp(config, failed[0].Batch.Objects[0])
+		}
+		return failed[0].Err
+	}
+
+	fmt.Printf("\n❌ 失败的批次:\n")
+	var failedObjects []string
+	for _, result := range failed {
+		fmt.Printf("  批次 %d/%d (%d 个): %v\n", result.Batch.Index, result.Batch.Total, len(result.Batch.Objects), result.Err)
+		failedObjects = append(failedObjects, result.Batch.Objects...)
+	}
+
+	file, err := writeFailedItems(config, failedObjects, refreshType, contentType)
+	if err != nil {
+		fmt.Printf("⚠️  保存失败列表失败: %v\n", err)
+	} else {
+		fmt.Printf("\n💾 失败的 %d 个对象已保存到: %s\n", len(failedObjects), file)
+		fmt.Printf("💡 只重试失败的批次: %s --network %s -t %s -f %s\n", os.Args[0], config.Network, refreshType, file)
+	}
+	return fmt.Errorf("%d/%d 个批次失败", len(failed), len(results))
+}
+
+// 失败的对象按 -f 的格式写入文件，目录已展开为完整URL
+// 多种类型同时刷新时每组各写一个文件，URL以外的文件名带上类型以免重名
+func writeFailedItems(config *AkamaiConfig, objects []string, refreshType, contentType string) (string, error) {
+	file := fmt.Sprintf("akamai_failed_%s.txt", time.Now().Format("20060102_150405"))
+	if contentType != "url" {
+		file = strings.TrimSuffix(file, ".txt") + "_" + contentType + ".txt"
+	}
+	var content strings.Builder
+	fmt.Fprintf(&content, "# 提交失败的刷新内容，网络: %s，类型: %s\n", config.Network, refreshType)
+	for _, object := range objects {
+		if contentType == "tag" {
+			object = tagFilePrefix + object
+		}
+		content.WriteString(object + "\n")
+	}
+	return file, os.WriteFile(file, []byte(content.String()), 0644)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func makeURLs(n, length int) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		prefix := fmt.Sprintf("https://cdn.example.com/%06d/", i)
+		urls[i] = prefix + strings.Repeat("a", length-len(prefix))
+	}
+	return urls
+}
+
+func TestSplitPurgeBatches(t *testing.T) {
+	fields := map[string]interface{}{"type": "delete"}
+	tests := []struct {
+		name        string
+		objects     []string
+		maxObjects  int
+		wantBatches int
+	}{
+		{"empty", nil, 0, 0},
+		{"single", []string{"https://cdn.example.com/a.js"}, 0, 1},
+		{"under body limit", makeURLs(100, 200), 0, 1},
+		{"over body limit", makeURLs(3000, 100), 0, 7},
+		{"object limit", makeURLs(12, 40), 5, 3},
+	}
+	for _, tt := range tests {
+		batches, err := splitPurgeBatches(tt.objects, fields, tt.maxObjects)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if len(batches) != tt.wantBatches {
+			t.Errorf("%s: got %d batches, want %d", tt.name, len(batches), tt.wantBatches)
+		}
+
+		var objects []string
+		for i, batch := range batches {
+			if batch.Index != i+1 || batch.Total != len(batches) {
+				t.Errorf("%s: batch %d has Index %d Total %d", tt.name, i, batch.Index, batch.Total)
+			}
+			if len(batch.Body) > maxPurgeBodyBytes {
+				t.Errorf("%s: batch %d body %d bytes over limit", tt.name, batch.Index, len(batch.Body))
+			}
+			if tt.maxObjects > 0 && len(batch.Objects) > tt.maxObjects {
+				t.Errorf("%s: batch %d has %d objects, limit %d", tt.name, batch.Index, len(batch.Objects), tt.maxObjects)
+			}
+
+			// 请求体与批次内容一致
+			var body struct {
+				Objects []string `json:"objects"`
+				Type    string   `json:"type"`
+			}
+			if err := json.Unmarshal(batch.Body, &body); err != nil {
+				t.Errorf("%s: batch %d body is not JSON: %v", tt.name, batch.Index, err)
+			}
+			if !reflect.DeepEqual(body.Objects, batch.Objects) || body.Type != "delete" {
+				t.Errorf("%s: batch %d body does not match its objects", tt.name, batch.Index)
+			}
+			objects = append(objects, batch.Objects...)
+		}
+		if len(tt.objects) > 0 && !reflect.DeepEqual(objects, tt.objects) {
+			t.Errorf("%s: batches do not keep all objects in order", tt.name)
+		}
+	}
+}
+
+func TestSplitPurgeBatchesEscaping(t *testing.T) {
+	// 每个 & 编码后占6个字节，按原始长度计算会超过上限
+	urls := make([]string, 400)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://cdn.example.com/q?%d", i) + strings.Repeat("&", 30)
+	}
+	batches, err := splitPurgeBatches(urls, map[string]interface{}{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) < 2 {
+		t.Fatalf("got %d batches, want at least 2", len(batches))
+	}
+	for _, batch := range batches {
+		if len(batch.Body) > maxPurgeBodyBytes {
+			t.Errorf("batch %d body %d bytes over limit", batch.Index, len(batch.Body))
+		}
+	}
+}
+
+func TestSplitPurgeBatchesOversizedObject(t *testing.T) {
+	huge := "https://cdn.example.com/" + strings.Repeat("a", maxPurgeBodyBytes)
+	if _, err := splitPurgeBatches([]string{huge}, nil, 0); err == nil {
+		t.Errorf("want error for an object over the body limit")
+	}
+}
+
+func TestPurgeBatchLabel(t *testing.T) {
+	tests := []struct {
+		batch purgeBatch
+		want  string
+	}{
+		{purgeBatch{Index: 1, Total: 1}, ""},
+		{purgeBatch{Index: 2, Total: 3}, "[批次 2/3] "},
+	}
+	for _, tt := range tests {
+		if got := tt.batch.label(); got != tt.want {
+			t.Errorf("label() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 配置项，配置文件和环境变量使用相同的名称
+type configKey struct {
+	Name    string
+	Secret  bool   // config show 时隐藏
+	Default string // 所有来源都没有设置时的值
+}
+
+var configKeys = []configKey{
+	{Name: "AKAMAI_CLIENT_TOKEN", Secret: true},
+	{Name: "AKAMAI_CLIENT_SECRET", Secret: true},
+	{Name: "AKAMAI_ACCESS_TOKEN", Secret: true},
+	{Name: "AKAMAI_BASE_URL"},
+	{Name: "DEFAULT_REFRESH_TYPE", Default: "delete"},
+	{Name: "CDN_DOMAIN"},
+	{Name: "AKAMAI_NETWORK", Default: "production"},
+	{Name: "ORIGIN_DOMAIN"},
+	{Name: "DIR_SOURCE"},
+	{Name: "AKAMAI_MAX_BODY", Default: "131072"},
+}
+
+// 一层配置来源，如命令行、环境变量或某个配置文件
+type configLayer struct {
+	Source string
+	File   string // 来源为文件时的路径
+	Values map[string]string
+}
+
+// 配置加载选项，来自命令行
+type configOptions struct {
+	ConfigFile string            // --config 指定的配置文件
+	EdgercFile string            // --edgerc 指定的.edgerc
+	Section    string            // --section 指定的.edgerc section
+	Flags      map[string]string // 命令行参数覆盖的配置项
+	FlagNames  map[string]string // 配置项 -> 参数名，用于显示来源
+}
+
+// 解析后的配置值和来源
+type resolvedValue struct {
+	Value  string
+	Source string
+}
+
+// 用户级配置文件: $XDG_CONFIG_HOME/akamai/akamai.conf，其次 ~/.akamai.conf
+func userConfigFiles() []string {
+	var files []string
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	home, err := os.UserHomeDir()
+	if configHome == "" && err == nil {
+		configHome = filepath.Join(home, ".config")
+	}
+	if configHome != "" {
+		files = append(files, filepath.Join(configHome, "akamai", "akamai.conf"))
+	}
+	if err == nil {
+		files = append(files, filepath.Join(home, ".akamai.conf"))
+	}
+	return files
+}
+
+// 当前目录附近的配置文件，与发布包的目录结构对应
+var localConfigFiles = []string{"../conf/akamai.conf", "conf/akamai.conf", "./akamai.conf"}
+
+// 第一个存在的文件
+func firstExisting(files []string) string {
+	for _, file := range files {
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	return ""
+}
+
+// 按优先级从高到低收集配置来源:
+// 命令行 > 环境变量 > --config、--edgerc/--section > 用户配置 (XDG/home、~/.edgerc) > 当前目录附近的配置文件
+func configLayers(options configOptions) ([]configLayer, error) {
+	var layers []configLayer
+
+	if len(options.Flags) > 0 {
+		layers = append(layers, configLayer{Source: "命令行", Values: options.Flags})
+	}
+
+	env := map[string]string{}
+	for _, key := range configKeys {
+		if value := os.Getenv(key.Name); value != "" {
+			env[key.Name] = value
+		}
+	}
+	if len(env) > 0 {
+		layers = append(layers, configLayer{Source: "环境变量", Values: env})
+	}
+
+	if options.ConfigFile != "" {
+		values, err := parseConfigFile(options.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, configLayer{Source: options.ConfigFile, File: options.ConfigFile, Values: values})
+	}
+
+	// 指定了.edgerc或section时必须能读到，否则只在 ~/.edgerc 存在时作为用户配置
+	edgercFile, section := edgercOptions(options)
+	explicitEdgerc := edgercFile != "" || section != ""
+	if edgercFile == "" {
+		edgercFile = defaultEdgercPath()
+	}
+	if section == "" {
+		section = defaultEdgercSection
+	}
+	edgercLayer := func() (configLayer, error) {
+		values, err := parseEdgerc(edgercFile, section)
+		return configLayer{Source: fmt.Sprintf("%s [%s]", edgercFile, section), File: edgercFile, Values: values}, err
+	}
+	if explicitEdgerc {
+		layer, err := edgercLayer()
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+
+	if file := firstExisting(userConfigFiles()); file != "" {
+		values, err := parseConfigFile(file)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, configLayer{Source: file, File: file, Values: values})
+	}
+	if !explicitEdgerc && firstExisting([]string{edgercFile}) != "" {
+		// ~/.edgerc 可能只给其他工具使用，读取失败时不影响其他配置来源
+		if layer, err := edgercLayer(); err == nil {
+			layers = append(layers, layer)
+		} else {
+			fmt.Printf("⚠️  跳过.edgerc: %v\n", err)
+		}
+	}
+
+	if file := firstExisting(localConfigFiles); file != "" {
+		values, err := parseConfigFile(file)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, configLayer{Source: file, File: file, Values: values})
+	}
+	return layers, nil
+}
+
+// --edgerc/--section，未指定时取环境变量AKAMAI_EDGERC/AKAMAI_EDGERC_SECTION
+func edgercOptions(options configOptions) (string, string) {
+	edgercFile, section := options.EdgercFile, options.Section
+	if edgercFile == "" {
+		edgercFile = os.Getenv("AKAMAI_EDGERC")
+	}
+	if section == "" {
+		section = os.Getenv("AKAMAI_EDGERC_SECTION")
+	}
+	return edgercFile, section
+}
+
+// 逐项取优先级最高的来源
+func resolveConfig(options configOptions) (map[string]resolvedValue, []configLayer, error) {
+	layers, err := configLayers(options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolved := map[string]resolvedValue{}
+	for _, key := range configKeys {
+		value := resolvedValue{Value: key.Default, Source: "默认值"}
+		if key.Default == "" {
+			value.Source = "未设置"
+		}
+		for _, layer := range layers {
+			if v, ok := layer.Values[key.Name]; ok && v != "" {
+				value = resolvedValue{Value: v, Source: layer.Source}
+				if layer.Source == "命令行" && options.FlagNames[key.Name] != "" {
+					value.Source = "命令行 " + options.FlagNames[key.Name]
+				}
+				break
+			}
+		}
+		resolved[key.Name] = value
+	}
+	return resolved, layers, nil
+}
+
+// 加载配置并检查必需项
+func loadAkamaiConfig(options configOptions) (*AkamaiConfig, error) {
+	resolved, layers, err := resolveConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &AkamaiConfig{
+		ClientToken:  resolved["AKAMAI_CLIENT_TOKEN"].Value,
+		ClientSecret: resolved["AKAMAI_CLIENT_SECRET"].Value,
+		AccessToken:  resolved["AKAMAI_ACCESS_TOKEN"].Value,
+		BaseURL:      resolved["AKAMAI_BASE_URL"].Value,
+		RefreshType:  resolved["DEFAULT_REFRESH_TYPE"].Value,
+		CDNDomain:    resolved["CDN_DOMAIN"].Value,
+		Network:      resolved["AKAMAI_NETWORK"].Value,
+		OriginDomain: resolved["ORIGIN_DOMAIN"].Value,
+		DirSource:    resolved["DIR_SOURCE"].Value,
+	}
+	maxBody, err := strconv.Atoi(resolved["AKAMAI_MAX_BODY"].Value)
+	if err != nil || maxBody <= 0 {
+		return nil, fmt.Errorf("无效的AKAMAI_MAX_BODY: %s (来自%s)",
+			resolved["AKAMAI_MAX_BODY"].Value, resolved["AKAMAI_MAX_BODY"].Source)
+	}
+	config.MaxBody = maxBody
+
+	for _, name := range []string{"AKAMAI_CLIENT_TOKEN", "AKAMAI_CLIENT_SECRET", "AKAMAI_ACCESS_TOKEN", "AKAMAI_BASE_URL"} {
+		if resolved[name].Value == "" {
+			return nil, fmt.Errorf("缺少%s (可通过环境变量、配置文件或.edgerc设置)", name)
+		}
+	}
+	if config.RefreshType != "delete" && config.RefreshType != "remove" {
+		return nil, fmt.Errorf("刷新类型必须是 delete 或 remove: %s (来自%s)",
+			config.RefreshType, resolved["DEFAULT_REFRESH_TYPE"].Source)
+	}
+	if config.Network != "staging" && config.Network != "production" {
+		return nil, fmt.Errorf("网络必须是 staging 或 production: %s (来自%s)",
+			config.Network, resolved["AKAMAI_NETWORK"].Source)
+	}
+
+	for _, layer := range layers {
+		if layer.File != "" {
+			fmt.Printf("✅ 已从配置文件加载Akamai配置: %s\n", layer.Source)
+		}
+	}
+	return config, nil
+}
+
+// 解析 KEY="value" 格式的配置文件
+func parseConfigFile(configPath string) (map[string]string, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	values := map[string]string{}
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s 第%d行格式错误，应为 KEY=\"value\": %s", configPath, i+1, line)
+		}
+
+		key := strings.TrimSpace(strings.TrimPrefix(parts[0], "export "))
+		value := strings.TrimSpace(parts[1])
+
+		// 移除引号
+		if len(value) >= 2 && ((value[0] == '"' && value[len(value)-1] == '"') ||
+			(value[0] == '\'' && value[len(value)-1] == '\'')) {
+			value = value[1 : len(value)-1]
+		}
+
+		if !isConfigKey(key) {
+			fmt.Printf("⚠️  %s 第%d行: 未知的配置项 %s\n", configPath, i+1, key)
+			continue
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func isConfigKey(name string) bool {
+	for _, key := range configKeys {
+		if key.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// 隐藏密钥，只保留首尾几位便于核对
+func maskSecret(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", 8) + value[len(value)-4:]
+}
+
+// config show: 显示每个配置项的最终值和来源
+func runConfigShow(options configOptions) error {
+	resolved, layers, err := resolveConfig(options)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n📋 生效的配置 (优先级: 命令行 > 环境变量 > --config/--edgerc > 用户配置 > 当前目录)\n\n")
+	for _, key := range configKeys {
+		value := resolved[key.Name]
+		display := value.Value
+		if key.Secret && display != "" {
+			display = maskSecret(display)
+		}
+		if display == "" {
+			display = "-"
+		}
+		fmt.Printf("  %-22s %-50s %s\n", key.Name, display, value.Source)
+	}
+
+	fmt.Printf("\n📂 配置来源:\n")
+	if len(layers) == 0 {
+		fmt.Printf("  (无)\n")
+	}
+	for _, layer := range layers {
+		names := make([]string, 0, len(layer.Values))
+		for name := range layer.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Printf("  %s: %s\n", layer.Source, strings.Join(names, ", "))
+	}
+
+	// 每组只使用第一个存在的文件
+	mark := func(file string) string {
+		for _, layer := range layers {
+			if layer.File == file {
+				return " ✓ 使用中"
+			}
+		}
+		if _, err := os.Stat(file); err == nil {
+			return " (存在，未使用)"
+		}
+		return ""
+	}
+	fmt.Printf("\n🔍 查找的配置文件:\n")
+	if options.ConfigFile != "" {
+		fmt.Printf("  --config  %s%s\n", options.ConfigFile, mark(options.ConfigFile))
+	}
+	edgercFile, _ := edgercOptions(options)
+	if edgercFile != "" {
+		fmt.Printf("  --edgerc  %s%s\n", edgercFile, mark(edgercFile))
+	}
+	for _, file := range userConfigFiles() {
+		fmt.Printf("  用户配置  %s%s\n", file, mark(file))
+	}
+	if edgercFile == "" {
+		fmt.Printf("  用户配置  %s%s\n", defaultEdgercPath(), mark(defaultEdgercPath()))
+	}
+	for _, file := range localConfigFiles {
+		fmt.Printf("  当前目录  %s%s\n", file, mark(file))
+	}
+	return nil
+}
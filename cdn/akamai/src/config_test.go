@@ -0,0 +1,247 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 隔离配置来源: 清空相关环境变量，HOME和当前目录指向空的临时目录
+func isolateConfig(t *testing.T) string {
+	t.Helper()
+	for _, key := range configKeys {
+		t.Setenv(key.Name, "")
+	}
+	t.Setenv("AKAMAI_EDGERC", "")
+	t.Setenv("AKAMAI_EDGERC_SECTION", "")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Chdir(t.TempDir())
+	return home
+}
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "quotes and export",
+			content: "# Akamai配置\nAKAMAI_BASE_URL=\"https://akab.example.net\"\nexport CDN_DOMAIN='https://cdn.example.com'\n  AKAMAI_NETWORK = staging  \n",
+			want: map[string]string{
+				"AKAMAI_BASE_URL": "https://akab.example.net",
+				"CDN_DOMAIN":      "https://cdn.example.com",
+				"AKAMAI_NETWORK":  "staging",
+			},
+		},
+		{name: "unknown key ignored", content: "UNKNOWN=1\nAKAMAI_NETWORK=staging\n", want: map[string]string{"AKAMAI_NETWORK": "staging"}},
+		{name: "value with =", content: "ORIGIN_DOMAIN=\"https://origin.example.com/?a=b\"\n", want: map[string]string{"ORIGIN_DOMAIN": "https://origin.example.com/?a=b"}},
+		{name: "unbalanced quote kept", content: "CDN_DOMAIN=\"https://cdn.example.com\n", want: map[string]string{"CDN_DOMAIN": "\"https://cdn.example.com"}},
+		{name: "missing =", content: "AKAMAI_NETWORK=staging\n\nAKAMAI_BASE_URL\n", wantErr: "第3行"},
+	}
+	for _, tt := range tests {
+		path := writeFile(t, filepath.Join(t.TempDir(), "akamai.conf"), tt.content)
+		got, err := parseConfigFile(path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseConfigFile() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveConfigPrecedence(t *testing.T) {
+	home := isolateConfig(t)
+	t.Setenv("AKAMAI_NETWORK", "production")
+	t.Setenv("DEFAULT_REFRESH_TYPE", "remove")
+
+	configFile := writeFile(t, filepath.Join(t.TempDir(), "custom.conf"),
+		"DEFAULT_REFRESH_TYPE=delete\nCDN_DOMAIN=https://config.example.com\nAKAMAI_BASE_URL=https://config-host\n")
+	edgercFile := writeFile(t, filepath.Join(t.TempDir(), "edgerc"),
+		"[ci]\nclient_token = edgerc-client\nhost = edgerc-host\n")
+	userFile := writeFile(t, filepath.Join(home, ".akamai.conf"),
+		"CDN_DOMAIN=https://user.example.com\nORIGIN_DOMAIN=https://user-origin.example.com\n")
+	writeFile(t, "akamai.conf", "ORIGIN_DOMAIN=https://local-origin.example.com\nDIR_SOURCE=local:./dist\n")
+
+	resolved, layers, err := resolveConfig(configOptions{
+		ConfigFile: configFile,
+		EdgercFile: edgercFile,
+		Section:    "ci",
+		Flags:      map[string]string{"AKAMAI_NETWORK": "staging"},
+		FlagNames:  map[string]string{"AKAMAI_NETWORK": "--network"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 6 {
+		t.Errorf("got %d config layers, want 6", len(layers))
+	}
+
+	tests := []struct {
+		key        string
+		wantValue  string
+		wantSource string
+	}{
+		{"AKAMAI_NETWORK", "staging", "命令行 --network"},
+		{"DEFAULT_REFRESH_TYPE", "remove", "环境变量"},
+		{"CDN_DOMAIN", "https://config.example.com", configFile},
+		{"AKAMAI_BASE_URL", "https://config-host", configFile},
+		{"AKAMAI_CLIENT_TOKEN", "edgerc-client", edgercFile + " [ci]"},
+		{"ORIGIN_DOMAIN", "https://user-origin.example.com", userFile},
+		{"DIR_SOURCE", "local:./dist", "./akamai.conf"},
+		{"AKAMAI_MAX_BODY", "131072", "默认值"},
+		{"AKAMAI_ACCESS_TOKEN", "", "未设置"},
+	}
+	for _, tt := range tests {
+		got := resolved[tt.key]
+		if got.Value != tt.wantValue || got.Source != tt.wantSource {
+			t.Errorf("%s = %q from %q, want %q from %q", tt.key, got.Value, got.Source, tt.wantValue, tt.wantSource)
+		}
+	}
+}
+
+func TestResolveConfigUserFiles(t *testing.T) {
+	home := isolateConfig(t)
+
+	// XDG配置优先于 ~/.akamai.conf，只使用第一个存在的文件
+	xdg := filepath.Join(home, "xdg")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	xdgFile := writeFile(t, filepath.Join(xdg, "akamai", "akamai.conf"), "CDN_DOMAIN=https://xdg.example.com\n")
+	writeFile(t, filepath.Join(home, ".akamai.conf"), "CDN_DOMAIN=https://home.example.com\nORIGIN_DOMAIN=https://home-origin.example.com\n")
+
+	resolved, _, err := resolveConfig(configOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resolved["CDN_DOMAIN"]; got.Value != "https://xdg.example.com" || got.Source != xdgFile {
+		t.Errorf("CDN_DOMAIN = %+v, want the XDG config", got)
+	}
+	if got := resolved["ORIGIN_DOMAIN"]; got.Value != "" {
+		t.Errorf("ORIGIN_DOMAIN = %+v, want unset", got)
+	}
+}
+
+func TestResolveConfigEdgerc(t *testing.T) {
+	home := isolateConfig(t)
+	const edgerc = "[default]\nclient_token = default-client\n\n[ci]\nclient_token = ci-client\n"
+
+	// 没有指定时使用 ~/.edgerc 的 [default]
+	writeFile(t, filepath.Join(home, ".edgerc"), edgerc)
+	resolved, _, err := resolveConfig(configOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resolved["AKAMAI_CLIENT_TOKEN"].Value; got != "default-client" {
+		t.Errorf("AKAMAI_CLIENT_TOKEN = %q, want default-client", got)
+	}
+
+	// 环境变量指定section
+	t.Setenv("AKAMAI_EDGERC_SECTION", "ci")
+	if resolved, _, err = resolveConfig(configOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolved["AKAMAI_CLIENT_TOKEN"].Value; got != "ci-client" {
+		t.Errorf("AKAMAI_CLIENT_TOKEN = %q, want ci-client", got)
+	}
+
+	// 明确指定但不存在的section报错
+	if _, _, err := resolveConfig(configOptions{Section: "missing"}); err == nil {
+		t.Errorf("resolveConfig() with a missing section: want error")
+	}
+	t.Setenv("AKAMAI_EDGERC_SECTION", "")
+
+	// ~/.edgerc 格式错误时跳过，不影响其他来源
+	writeFile(t, filepath.Join(home, ".edgerc"), "client_token = no-section\n")
+	t.Setenv("AKAMAI_BASE_URL", "https://env-host")
+	if resolved, _, err = resolveConfig(configOptions{}); err != nil {
+		t.Fatalf("resolveConfig() with a broken ~/.edgerc: %v", err)
+	}
+	if got := resolved["AKAMAI_BASE_URL"].Value; got != "https://env-host" {
+		t.Errorf("AKAMAI_BASE_URL = %q, want https://env-host", got)
+	}
+}
+
+func TestLoadAkamaiConfigValidation(t *testing.T) {
+	credentials := map[string]string{
+		"AKAMAI_CLIENT_TOKEN":  "ct",
+		"AKAMAI_CLIENT_SECRET": "cs",
+		"AKAMAI_ACCESS_TOKEN":  "at",
+		"AKAMAI_BASE_URL":      "https://akab.example.net",
+	}
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "valid", env: map[string]string{"AKAMAI_NETWORK": "staging", "AKAMAI_MAX_BODY": "65536"}},
+		{name: "invalid network", env: map[string]string{"AKAMAI_NETWORK": "prod"}, wantErr: "来自环境变量"},
+		{name: "invalid refresh type", env: map[string]string{"DEFAULT_REFRESH_TYPE": "purge"}, wantErr: "delete 或 remove"},
+		{name: "invalid max body", env: map[string]string{"AKAMAI_MAX_BODY": "0"}, wantErr: "AKAMAI_MAX_BODY"},
+		{name: "missing secret", env: map[string]string{"AKAMAI_CLIENT_SECRET": ""}, wantErr: "缺少AKAMAI_CLIENT_SECRET"},
+	}
+	for _, tt := range tests {
+		isolateConfig(t)
+		for key, value := range credentials {
+			os.Setenv(key, value)
+		}
+		for key, value := range tt.env {
+			os.Setenv(key, value)
+		}
+
+		config, err := loadAkamaiConfig(configOptions{})
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if config.Network != "staging" || config.MaxBody != 65536 || config.RefreshType != "delete" || config.ClientSecret != "cs" {
+			t.Errorf("%s: loadAkamaiConfig() = %+v", tt.name, config)
+		}
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"short", "*****"},
+		{"12345678", "********"},
+		{"akab-abcdefghijkl", "akab********ijkl"},
+	}
+	for _, tt := range tests {
+		if got := maskSecret(tt.value); got != tt.want {
+			t.Errorf("maskSecret(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
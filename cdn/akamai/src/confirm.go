@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const maxConfirmSamples = 5 // 确认提示中显示的示例数
+
+var contentTypeNames = map[string]string{
+	"url":       "URL",
+	"directory": "目录",
+	"cpcode":    "CPCode",
+	"tag":       "Cache Tag",
+}
+
+// 标准输入是否是终端；/dev/null 也是字符设备，需要排除
+func isInteractive() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
+}
+
+// 执行前确认: --yes/--force 跳过；非交互环境没有 --yes 时拒绝执行
+func confirmPurge(config *AkamaiConfig, groups []*purgeGroup, refreshType string) (bool, error) {
+	if config.AssumeYes {
+		return true, nil
+	}
+	if !isInteractive() {
+		return false, fmt.Errorf("当前不是交互式终端，无法确认；在CI或脚本中请加上 --yes")
+	}
+
+	action := "invalidate (标记过期)"
+	if refreshType == "remove" {
+		action = "delete (删除缓存)"
+	}
+	fmt.Printf("\n📋 即将执行Akamai CDN刷新:\n")
+	if len(groups) == 1 {
+		fmt.Printf("  类型: %s\n", groups[0].name())
+	}
+	fmt.Printf("  操作: %s\n", action)
+	fmt.Printf("  网络: %s\n", networkLabel(config.Network))
+	for _, group := range groups {
+		label := "数量"
+		if len(groups) > 1 {
+			label = group.name()
+		}
+		if len(group.Batches) > 1 {
+			fmt.Printf("  %s: %d (分 %d 批提交)\n", label, len(group.Objects), len(group.Batches))
+		} else {
+			fmt.Printf("  %s: %d\n", label, len(group.Objects))
+		}
+		for i, object := range group.Objects {
+			if i == maxConfirmSamples {
+				fmt.Printf("    ... 还有 %d 个\n", len(group.Objects)-maxConfirmSamples)
+				break
+			}
+			fmt.Printf("    %s\n", object)
+		}
+	}
+
+	fmt.Printf("确认执行? [y/N]: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Println()
+		return false, fmt.Errorf("无法读取确认输入，非交互环境请加上 --yes")
+	}
+	confirm := strings.ToLower(strings.TrimSpace(line))
+	return confirm == "y" || confirm == "yes", nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestConfirmPurgeNonInteractive(t *testing.T) {
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	writer.WriteString("y\n")
+	writer.Close()
+
+	group := &purgeGroup{ContentType: "url", Objects: []string{"https://cdn.example.com/a.js"}}
+	tests := []struct {
+		name      string
+		stdin     *os.File
+		assumeYes bool
+		want      bool
+		wantErr   bool
+	}{
+		{"--yes", devNull, true, true, false},
+		{"/dev/null", devNull, false, false, true},
+		// 管道输入即使是y也不算确认
+		{"pipe", reader, false, false, true},
+	}
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+	for _, tt := range tests {
+		os.Stdin = tt.stdin
+		got, err := confirmPurge(&AkamaiConfig{AssumeYes: tt.assumeYes}, []*purgeGroup{group}, "delete")
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s: confirmPurge() = %v, %v, want %v, wantErr %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
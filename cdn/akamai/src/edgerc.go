@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultEdgercSection = "default"
+
+// .edgerc 字段到配置项的对应关系，与Akamai其他工具通用
+var edgercKeys = map[string]string{
+	"client_token":  "AKAMAI_CLIENT_TOKEN",
+	"client_secret": "AKAMAI_CLIENT_SECRET",
+	"access_token":  "AKAMAI_ACCESS_TOKEN",
+	"host":          "AKAMAI_BASE_URL",
+	"max-body":      "AKAMAI_MAX_BODY",
+	"max_body":      "AKAMAI_MAX_BODY",
+}
+
+func defaultEdgercPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".edgerc")
+}
+
+// 读取 .edgerc 中的一个section:
+//
+//	[default]
+//	client_secret = xxx
+//	host = akab-xxx.luna.akamaiapis.net
+//	access_token = akab-xxx
+//	client_token = akab-xxx
+//	max-body = 131072
+func parseEdgerc(path, section string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取.edgerc失败: %v", err)
+	}
+
+	sections := map[string]map[string]string{}
+	current := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			if sections[current] == nil {
+				sections[current] = map[string]string{}
+			}
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || current == "" {
+			return nil, fmt.Errorf("%s 第%d行格式错误: %s", path, lineNo, line)
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+		sections[current][key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取.edgerc失败: %v", err)
+	}
+
+	fields, ok := sections[section]
+	if !ok {
+		names := make([]string, 0, len(sections))
+		for name := range sections {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%s 中没有section [%s]，可用: %s", path, section, strings.Join(names, ", "))
+	}
+
+	values := map[string]string{}
+	for field, value := range fields {
+		key, ok := edgercKeys[field]
+		if !ok {
+			fmt.Printf("⚠️  %s [%s]: 忽略未知字段 %s\n", path, section, field)
+			continue
+		}
+		switch key {
+		case "AKAMAI_BASE_URL":
+			// .edgerc 中的host不带协议
+			if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+				value = "https://" + strings.TrimSuffix(value, "/")
+			}
+		case "AKAMAI_MAX_BODY":
+			if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+				return nil, fmt.Errorf("%s [%s]: 无效的max-body: %s", path, section, value)
+			}
+		}
+		values[key] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeEdgerc(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".edgerc")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseEdgerc(t *testing.T) {
+	const edgerc = `
+; Akamai凭证
+[default]
+client_secret = secret
+host = akab-host.luna.akamaiapis.net/
+access_token = akab-access
+client_token = akab-client
+max-body = 131072
+
+# staging
+[staging]
+client_secret = "quoted"
+host = https://staging.luna.akamaiapis.net
+access_token = 'a'
+client_token = c
+`
+	path := writeEdgerc(t, edgerc)
+
+	tests := []struct {
+		section string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			section: "default",
+			want: map[string]string{
+				"AKAMAI_CLIENT_SECRET": "secret",
+				"AKAMAI_BASE_URL":      "https://akab-host.luna.akamaiapis.net",
+				"AKAMAI_ACCESS_TOKEN":  "akab-access",
+				"AKAMAI_CLIENT_TOKEN":  "akab-client",
+				"AKAMAI_MAX_BODY":      "131072",
+			},
+		},
+		{
+			section: "staging",
+			want: map[string]string{
+				"AKAMAI_CLIENT_SECRET": "quoted",
+				"AKAMAI_BASE_URL":      "https://staging.luna.akamaiapis.net",
+				"AKAMAI_ACCESS_TOKEN":  "a",
+				"AKAMAI_CLIENT_TOKEN":  "c",
+			},
+		},
+		{section: "missing", wantErr: "可用: default, staging"},
+	}
+	for _, tt := range tests {
+		got, err := parseEdgerc(path, tt.section)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseEdgerc(%q) error = %v, want containing %q", tt.section, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseEdgerc(%q) error = %v", tt.section, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseEdgerc(%q) = %v, want %v", tt.section, got, tt.want)
+		}
+	}
+}
+
+func TestParseEdgercErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"field before section", "host = x\n[default]\n", "第1行格式错误"},
+		{"line without =", "[default]\nhost\n", "第2行格式错误"},
+		{"invalid max-body", "[default]\nmax_body = -1\n", "无效的max-body"},
+	}
+	for _, tt := range tests {
+		_, err := parseEdgerc(writeEdgerc(t, tt.content), "default")
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if _, err := parseEdgerc(filepath.Join(t.TempDir(), "none"), "default"); err == nil {
+		t.Errorf("missing file: want error")
+	}
+}
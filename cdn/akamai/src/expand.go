@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxURLs   = 10000 // 目录展开后超过此数量时需要改用CPCode或tag刷新
+	maxPreviewedURLs = 10    // 预览时每个目录显示的URL数
+)
+
+// 目录展开的来源，由 --source 或 DIR_SOURCE 指定:
+//
+//	local:./dist                 本地构建目录，对应CDN_DOMAIN的根目录
+//	oss://bucket[/前缀]          OSS bucket中的对象，前缀对应CDN_DOMAIN的根目录
+//	sitemap:FILE|URL             sitemap.xml中的<loc>
+//	manifest:FILE                清单文件: 每行一个URL或路径，或JSON数组 (字符串或带remote字段的对象)
+type dirSource struct {
+	Kind string // local、oss、sitemap、manifest
+	Path string // 本地目录、bucket、sitemap或清单的位置
+	Root string // oss: 对应CDN根目录的对象前缀
+}
+
+func parseDirSource(spec string) (*dirSource, error) {
+	if rest, ok := strings.CutPrefix(spec, "oss://"); ok {
+		bucket, root, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("无效的目录来源 %s: 缺少bucket", spec)
+		}
+		if root != "" && !strings.HasSuffix(root, "/") {
+			root += "/"
+		}
+		return &dirSource{Kind: "oss", Path: bucket, Root: root}, nil
+	}
+
+	kind, path, ok := strings.Cut(spec, ":")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("无效的目录来源 %s (应为 local:目录、oss://bucket/前缀、sitemap:文件 或 manifest:文件)", spec)
+	}
+	switch kind {
+	case "local", "sitemap", "manifest":
+		return &dirSource{Kind: kind, Path: path}, nil
+	}
+	return nil, fmt.Errorf("未知的目录来源类型 %s (可用: local、oss、sitemap、manifest)", kind)
+}
+
+func (s *dirSource) String() string {
+	if s.Kind == "oss" {
+		return "oss://" + s.Path + "/" + s.Root
+	}
+	return s.Kind + ":" + s.Path
+}
+
+// 把目录刷新展开为目录下的所有URL；展开后数量超过maxURLs时改用fallback (cpcode:CODE 或 tag:TAG)
+func expandDirectories(config *AkamaiConfig, dirs []string, maxURLs int, fallback string) ([]string, string, error) {
+	source, err := parseDirSource(config.DirSource)
+	if err != nil {
+		return nil, "", err
+	}
+	if config.CDNDomain == "" {
+		return nil, "", fmt.Errorf("目录展开需要在配置文件中设置CDN_DOMAIN")
+	}
+	cdnBase := strings.TrimSuffix(config.CDNDomain, "/")
+
+	// sitemap和清单只读取一次，再按目录筛选
+	var listed []string
+	if source.Kind == "sitemap" || source.Kind == "manifest" {
+		if listed, err = loadSourceList(source); err != nil {
+			return nil, "", err
+		}
+	}
+
+	fmt.Printf("📂 按 %s 展开 %d 个目录\n", source, len(dirs))
+	seen := map[string]bool{}
+	var urls []string
+	for _, dir := range dirs {
+		dir, err = directoryPath(cdnBase, dir)
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.HasSuffix(dir, "/") {
+			dir += "/"
+		}
+
+		var paths []string
+		switch source.Kind {
+		case "local":
+			paths, err = listLocalDir(source.Path, dir)
+		case "oss":
+			paths, err = listOSSPrefix(source, dir)
+		default:
+			paths = filterListed(listed, dir)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("展开目录 %s 失败: %v", dir, err)
+		}
+
+		var dirURLs []string
+		for _, path := range paths {
+			u := cdnBase + (&url.URL{Path: path}).EscapedPath()
+			if !seen[u] {
+				seen[u] = true
+				dirURLs = append(dirURLs, u)
+			}
+		}
+		fmt.Printf("  %s → %d 个URL\n", dir, len(dirURLs))
+		for i, u := range dirURLs {
+			if i == maxPreviewedURLs {
+				fmt.Printf("    ... 还有 %d 个\n", len(dirURLs)-maxPreviewedURLs)
+				break
+			}
+			fmt.Printf("    %s\n", u)
+		}
+		urls = append(urls, dirURLs...)
+	}
+
+	if len(urls) == 0 {
+		return nil, "", fmt.Errorf("目录展开后没有任何URL，请检查目录和来源 %s", source)
+	}
+	if len(urls) <= maxURLs {
+		fmt.Printf("📋 共展开为 %d 个URL\n", len(urls))
+		return urls, "url", nil
+	}
+
+	if fallback == "" {
+		return nil, "", fmt.Errorf("目录展开后共 %d 个URL，超过上限 %d；可以用 --fallback cpcode:CODE 或 --fallback tag:TAG 改为整体刷新，或调大 --max-urls", len(urls), maxURLs)
+	}
+	kind, value, _ := strings.Cut(fallback, ":")
+	fmt.Printf("⚠️  目录展开后共 %d 个URL，超过上限 %d，改为按%s刷新: %s\n", len(urls), maxURLs, kind, value)
+	if kind == "cpcode" {
+		return []string{value}, "cpcode", nil
+	}
+	return []string{value}, "tag", nil
+}
+
+// 目录的URL路径；写成URL的目录必须属于CDN_DOMAIN，只取路径部分
+func directoryPath(cdnBase, dir string) (string, error) {
+	if !strings.HasPrefix(dir, "http://") && !strings.HasPrefix(dir, "https://") {
+		return normalizePath(dir), nil
+	}
+	u, err := url.Parse(dir)
+	if err != nil {
+		return "", fmt.Errorf("无效的目录URL %s: %v", dir, err)
+	}
+	base, err := url.Parse(cdnBase)
+	if err != nil || !strings.EqualFold(u.Host, base.Host) {
+		return "", fmt.Errorf("目录 %s 不属于CDN_DOMAIN %s，无法展开", dir, cdnBase)
+	}
+	if u.Path == "" {
+		return "/", nil
+	}
+	return u.Path, nil
+}
+
+// 检查 --fallback 的格式
+func validateFallback(fallback string) error {
+	kind, value, _ := strings.Cut(fallback, ":")
+	switch kind {
+	case "cpcode":
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("--fallback cpcode: 后应为数字CPCode: %s", fallback)
+		}
+		return nil
+	case "tag":
+		return validateTag(value)
+	}
+	return fmt.Errorf("--fallback 应为 cpcode:CODE 或 tag:TAG: %s", fallback)
+}
+
+// 本地构建目录中dir对应的文件，返回以/开头的URL路径
+func listLocalDir(root, dir string) ([]string, error) {
+	base := filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(dir, "/")))
+	info, err := os.Stat(base)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s 不是目录", base)
+	}
+
+	var paths []string
+	err = filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, dir+filepath.ToSlash(rel))
+		return nil
+	})
+	return paths, err
+}
+
+// sitemap或清单中的所有URL/路径
+func loadSourceList(source *dirSource) ([]string, error) {
+	data, err := readSource(source.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.Kind == "sitemap" {
+		var sitemap struct {
+			URLs []struct {
+				Loc string `xml:"loc"`
+			} `xml:"url"`
+		}
+		if err := xml.Unmarshal(data, &sitemap); err != nil {
+			return nil, fmt.Errorf("解析sitemap失败: %v", err)
+		}
+		var list []string
+		for _, u := range sitemap.URLs {
+			list = append(list, strings.TrimSpace(u.Loc))
+		}
+		return list, nil
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("解析清单失败: %v", err)
+		}
+		var list []string
+		for _, entry := range entries {
+			var value string
+			if json.Unmarshal(entry, &value) != nil {
+				// 兼容OSS上传工具的清单格式 [{"local": ..., "remote": ...}]
+				var item struct {
+					Remote string `json:"remote"`
+					URL    string `json:"url"`
+				}
+				if err := json.Unmarshal(entry, &item); err != nil {
+					return nil, fmt.Errorf("解析清单失败: %v", err)
+				}
+				value = item.URL
+				if value == "" {
+					value = item.Remote
+				}
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	}
+
+	var list []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			list = append(list, line)
+		}
+	}
+	return list, scanner.Err()
+}
+
+func readSource(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %v", location, err)
+		}
+		return data, nil
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, fmt.Errorf("下载 %s 失败: %v", location, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载 %s 失败: HTTP %d", location, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// 筛选dir下的条目，条目可以是完整URL或路径，返回以/开头的URL路径
+func filterListed(listed []string, dir string) []string {
+	var paths []string
+	for _, entry := range listed {
+		path := entry
+		if strings.HasPrefix(entry, "http://") || strings.HasPrefix(entry, "https://") {
+			u, err := url.Parse(entry)
+			if err != nil {
+				continue
+			}
+			path = u.Path
+		} else if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		if strings.HasPrefix(path, dir) && !strings.HasSuffix(path, "/") {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// 列出OSS中dir对应前缀下的对象，使用与OSS上传工具相同的环境变量:
+// OSS_ENDPOINT、OSS_ACCESS_KEY_ID、OSS_ACCESS_KEY_SECRET、OSS_SESSION_TOKEN (可选)
+func listOSSPrefix(source *dirSource, dir string) ([]string, error) {
+	endpoint := os.Getenv("OSS_ENDPOINT")
+	accessKeyID := os.Getenv("OSS_ACCESS_KEY_ID")
+	accessKeySecret := os.Getenv("OSS_ACCESS_KEY_SECRET")
+	if endpoint == "" || accessKeyID == "" || accessKeySecret == "" {
+		return nil, fmt.Errorf("列出OSS对象需要设置OSS_ENDPOINT、OSS_ACCESS_KEY_ID、OSS_ACCESS_KEY_SECRET")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("无效的OSS_ENDPOINT: %s", endpoint)
+	}
+
+	// IP或localhost使用path-style，其余使用bucket.endpoint
+	bucketURL := *base
+	host := base.Hostname()
+	if net.ParseIP(host) != nil || !strings.Contains(host, ".") {
+		bucketURL.Path = "/" + source.Path + "/"
+	} else {
+		bucketURL.Host = source.Path + "." + base.Host
+		bucketURL.Path = "/"
+	}
+
+	prefix := source.Root + strings.TrimPrefix(dir, "/")
+	var paths []string
+	marker := ""
+	client := &http.Client{Timeout: 30 * time.Second}
+	for {
+		query := url.Values{"prefix": {prefix}, "max-keys": {"1000"}}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		listURL := bucketURL
+		listURL.RawQuery = query.Encode()
+
+		req, err := http.NewRequest("GET", listURL.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		date := time.Now().UTC().Format(http.TimeFormat)
+		req.Header.Set("Date", date)
+		canonicalHeaders := ""
+		if token := os.Getenv("OSS_SESSION_TOKEN"); token != "" {
+			req.Header.Set("x-oss-security-token", token)
+			canonicalHeaders = "x-oss-security-token:" + token + "\n"
+		}
+		stringToSign := "GET\n\n\n" + date + "\n" + canonicalHeaders + "/" + source.Path + "/"
+		mac := hmac.New(sha1.New, []byte(accessKeySecret))
+		mac.Write([]byte(stringToSign))
+		req.Header.Set("Authorization", "OSS "+accessKeyID+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("列出OSS对象失败: %v", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取OSS响应失败: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("列出OSS对象失败: HTTP %d - %s", resp.StatusCode, string(body))
+		}
+
+		var result struct {
+			IsTruncated bool   `xml:"IsTruncated"`
+			NextMarker  string `xml:"NextMarker"`
+			Contents    []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+		}
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("解析OSS响应失败: %v", err)
+		}
+		for _, object := range result.Contents {
+			// 跳过以/结尾的目录占位对象
+			if !strings.HasSuffix(object.Key, "/") {
+				paths = append(paths, "/"+strings.TrimPrefix(object.Key, source.Root))
+			}
+		}
+		if !result.IsTruncated || result.NextMarker == "" {
+			break
+		}
+		marker = result.NextMarker
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
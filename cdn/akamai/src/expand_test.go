@@ -0,0 +1,193 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDirSource(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    *dirSource
+		wantErr bool
+	}{
+		{"local:./dist", &dirSource{Kind: "local", Path: "./dist"}, false},
+		{"sitemap:https://example.com/sitemap.xml", &dirSource{Kind: "sitemap", Path: "https://example.com/sitemap.xml"}, false},
+		{"manifest:upload.json", &dirSource{Kind: "manifest", Path: "upload.json"}, false},
+		{"oss://bucket", &dirSource{Kind: "oss", Path: "bucket"}, false},
+		{"oss://bucket/site", &dirSource{Kind: "oss", Path: "bucket", Root: "site/"}, false},
+		{"oss://bucket/site/", &dirSource{Kind: "oss", Path: "bucket", Root: "site/"}, false},
+		{"oss:///site", nil, true},
+		{"local:", nil, true},
+		{"./dist", nil, true},
+		{"ftp:host", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDirSource(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDirSource(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseDirSource(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestValidateFallback(t *testing.T) {
+	tests := []struct {
+		fallback string
+		wantErr  bool
+	}{
+		{"cpcode:12345", false},
+		{"tag:game-v1.2.0", false},
+		{"cpcode:abc", true},
+		{"tag:", true},
+		{"tag:a b", true},
+		{"url:https://example.com/", true},
+		{"12345", true},
+	}
+	for _, tt := range tests {
+		if err := validateFallback(tt.fallback); (err != nil) != tt.wantErr {
+			t.Errorf("validateFallback(%q) error = %v, wantErr %v", tt.fallback, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadSourceList(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "sitemap",
+			kind: "sitemap",
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://cdn.example.com/a.html </loc></url>
+  <url><loc>https://cdn.example.com/static/b.js</loc><lastmod>2024-01-01</lastmod></url>
+</urlset>`,
+			want: []string{"https://cdn.example.com/a.html", "https://cdn.example.com/static/b.js"},
+		},
+		{name: "invalid sitemap", kind: "sitemap", content: "<urlset><url>", wantErr: true},
+		{
+			name:    "line manifest",
+			kind:    "manifest",
+			content: "# 构建产物\n/static/a.js\n\n  https://cdn.example.com/b.js  \nstatic/c.css\n",
+			want:    []string{"/static/a.js", "https://cdn.example.com/b.js", "static/c.css"},
+		},
+		{
+			name:    "json strings",
+			kind:    "manifest",
+			content: `["/static/a.js", "https://cdn.example.com/b.js"]`,
+			want:    []string{"/static/a.js", "https://cdn.example.com/b.js"},
+		},
+		{
+			name:    "json objects",
+			kind:    "manifest",
+			content: ` [{"local": "dist/a.js", "remote": "static/a.js"}, {"url": "https://cdn.example.com/b.js", "remote": "static/b.js"}]`,
+			want:    []string{"static/a.js", "https://cdn.example.com/b.js"},
+		},
+		{name: "invalid json", kind: "manifest", content: `["a", 1]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "source")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := loadSourceList(&dirSource{Kind: tt.kind, Path: path})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: loadSourceList() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: loadSourceList() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterListed(t *testing.T) {
+	listed := []string{
+		"https://cdn.example.com/static/a.js",
+		"https://cdn.example.com/static/",
+		"/static/css/b.css",
+		"static/c.png",
+		"/static2/d.js",
+		"/other/e.js",
+	}
+	tests := []struct {
+		dir  string
+		want []string
+	}{
+		{"/static/", []string{"/static/a.js", "/static/css/b.css", "/static/c.png"}},
+		{"/static/css/", []string{"/static/css/b.css"}},
+		{"/", []string{"/static/a.js", "/static/css/b.css", "/static/c.png", "/static2/d.js", "/other/e.js"}},
+		{"/none/", nil},
+	}
+	for _, tt := range tests {
+		if got := filterListed(listed, tt.dir); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filterListed(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestDirectoryPath(t *testing.T) {
+	tests := []struct {
+		dir     string
+		want    string
+		wantErr bool
+	}{
+		{"/static/", "/static/", false},
+		{"static/css", "/static/css", false},
+		{"https://cdn.example.com/static/", "/static/", false},
+		{"http://CDN.example.com/static/css", "/static/css", false},
+		{"https://cdn.example.com", "/", false},
+		// 其他域名的目录无法按来源展开
+		{"https://other.example.com/static/", "", true},
+		{"https://cdn.example.com:8443/static/", "", true},
+	}
+	for _, tt := range tests {
+		got, err := directoryPath("https://cdn.example.com", tt.dir)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("directoryPath(%q) error = %v, wantErr %v", tt.dir, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("directoryPath(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestListLocalDir(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{"static/a.js", "static/css/b.css", "index.html"} {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := listLocalDir(root, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/static/a.js", "/static/css/b.css"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("listLocalDir() = %q, want %q", got, want)
+	}
+
+	if _, err := listLocalDir(root, "/missing/"); err == nil {
+		t.Errorf("listLocalDir() of a missing directory: want error")
+	}
+	if _, err := listLocalDir(root, "/index.html/"); err == nil {
+		t.Errorf("listLocalDir() of a file: want error")
+	}
+}
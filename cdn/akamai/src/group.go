@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 刷新内容的类型，多种类型同时刷新时按这个顺序提交
+var contentTypeOrder = []string{"url", "directory", "cpcode", "tag"}
+
+// 按类型分组的刷新内容
+type itemGroups map[string][]string
+
+func (g itemGroups) add(contentType string, items ...string) {
+	g[contentType] = append(g[contentType], items...)
+}
+
+func (g itemGroups) count() int {
+	n := 0
+	for _, items := range g {
+		n += len(items)
+	}
+	return n
+}
+
+// 有内容的类型，按contentTypeOrder排序
+func (g itemGroups) types() []string {
+	var types []string
+	for _, contentType := range contentTypeOrder {
+		if len(g[contentType]) > 0 {
+			types = append(types, contentType)
+		}
+	}
+	return types
+}
+
+// 按格式判断单个内容的类型，返回类型和内容 (Cache Tag去掉tag:前缀)
+func classifyItem(item string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(item, "http://") || strings.HasPrefix(item, "https://"):
+		return "url", item, nil
+	case strings.HasPrefix(item, "/"):
+		return "directory", item, nil
+	case strings.HasPrefix(item, tagFilePrefix):
+		tag := strings.TrimSpace(strings.TrimPrefix(item, tagFilePrefix))
+		if err := validateTag(tag); err != nil {
+			return "", "", err
+		}
+		return "tag", tag, nil
+	}
+	if _, err := strconv.Atoi(item); err == nil {
+		return "cpcode", item, nil
+	}
+	return "", "", fmt.Errorf("无法识别 %s 的类型 (应该是URL、以/开头的目录路径、纯数字CPCode或tag:开头的Cache Tag)", item)
+}
+
+// 一组同类型的刷新内容，对应一个CCU接口
+type purgeGroup struct {
+	ContentType string
+	Objects     []string
+	APIURL      string
+	Batches     []purgeBatch
+	Results     []batchResult
+	Err         error
+}
+
+func (g *purgeGroup) name() string {
+	return contentTypeNames[g.ContentType]
+}
+
+// 构造一组刷新请求: 规范化内容、选择接口并按请求体上限分批
+func preparePurgeGroup(config *AkamaiConfig, items []string, refreshType, contentType string) (*purgeGroup, error) {
+	group := &purgeGroup{ContentType: contentType}
+	var fields map[string]interface{} // objects以外的请求字段
+	maxObjects := 0
+
+	if contentType == "cpcode" {
+		fmt.Printf("🔢 准备刷新 %d 个CPCode\n", len(items))
+		group.Objects = items
+		fields = map[string]interface{}{
+			"type": refreshType,
+		}
+		group.APIURL = config.BaseURL + purgeEndpoint("cpcode", refreshType, config.Network)
+	} else if contentType == "tag" {
+		tags, err := normalizeTags(items)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("🏷️  准备刷新 %d 个Cache Tag\n", len(tags))
+		group.Objects = tags
+		fields = map[string]interface{}{}
+		maxObjects = maxTagsPerRequest
+		group.APIURL = config.BaseURL + purgeEndpoint("tag", refreshType, config.Network)
+	} else {
+		// 规范化所有路径，对于目录路径转换为完整URL
+		normalizedItems := make([]string, len(items))
+		for i, item := range items {
+			normalized := normalizePath(item)
+			if contentType == "directory" && !strings.HasPrefix(normalized, "http") {
+				if config.CDNDomain != "" {
+					normalizedItems[i] = strings.TrimSuffix(config.CDNDomain, "/") + normalized
+				} else {
+					return nil, fmt.Errorf("目录刷新需要在配置文件中设置CDN_DOMAIN")
+				}
+			} else {
+				normalizedItems[i] = normalized
+			}
+		}
+		group.Objects = normalizedItems
+		fields = map[string]interface{}{
+			"type": refreshType,
+		}
+		group.APIURL = config.BaseURL + purgeEndpoint("url", refreshType, config.Network)
+	}
+
+	// 超过请求体上限时自动分批
+	batches, err := splitPurgeBatches(group.Objects, fields, maxObjects)
+	if err != nil {
+		return nil, err
+	}
+	group.Batches = batches
+	return group, nil
+}
+
+// 提交一组刷新请求，汇总结果，需要时验证
+func submitPurgeGroup(config *AkamaiConfig, group *purgeGroup, refreshType string) error {
+	if config.Verbose {
+		fmt.Printf("📡 API URL: %s\n", group.APIURL)
+	}
+	if len(group.Batches) > 1 {
+		concurrency := config.Concurrency
+		if concurrency <= 0 {
+			concurrency = defaultPurgeConcurrency
+		}
+		fmt.Printf("📦 共 %d 个对象，超过单次请求上限，分为 %d 批提交 (并发 %d)\n", len(group.Objects), len(group.Batches), concurrency)
+	}
+
+	submitted := time.Now()
+	group.Results = submitPurgeBatches(config, group.APIURL, group.Batches)
+	err := reportPurgeResults(config, group.Results, refreshType, group.ContentType)
+	if config.Verify {
+		if verifyErr := verifyPurge(config, group.Results, group.ContentType, submitted); verifyErr != nil && err == nil {
+			err = verifyErr
+		}
+	}
+	return err
+}
+
+// 多种类型同时刷新时，按组列出结果
+func printGroupSummary(groups []*purgeGroup) error {
+	failed := 0
+	fmt.Printf("\n📋 分组结果:\n")
+	for _, group := range groups {
+		var purgeIDs []string
+		for _, result := range group.Results {
+			if result.Err == nil && result.Response.PurgeID != "" {
+				purgeIDs = append(purgeIDs, result.Response.PurgeID)
+			}
+		}
+		if group.Err != nil {
+			failed++
+			fmt.Printf("  ❌ %s: %d 个, %v\n", group.name(), len(group.Objects), group.Err)
+		} else {
+			fmt.Printf("  ✅ %s: %d 个, 任务ID: %s\n", group.name(), len(group.Objects), strings.Join(purgeIDs, ","))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 组刷新失败", failed, len(groups))
+	}
+	return nil
+}
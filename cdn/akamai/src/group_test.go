@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClassifyItem(t *testing.T) {
+	tests := []struct {
+		item     string
+		wantType string
+		want     string
+		wantErr  bool
+	}{
+		{"https://cdn.example.com/a.js", "url", "https://cdn.example.com/a.js", false},
+		{"http://cdn.example.com/", "url", "http://cdn.example.com/", false},
+		{"/static/css/", "directory", "/static/css/", false},
+		{"1892943", "cpcode", "1892943", false},
+		{"tag:game-v1.2.0", "tag", "game-v1.2.0", false},
+		{"tag: spaced ", "tag", "spaced", false},
+		{"tag:2024", "tag", "2024", false},
+		{"tag:", "", "", true},
+		{"tag:a b", "", "", true},
+		{"game-v1.2.0", "", "", true},
+		{"cdn.example.com/a.js", "", "", true},
+		{"12a", "", "", true},
+	}
+	for _, tt := range tests {
+		gotType, got, err := classifyItem(tt.item)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("classifyItem(%q) error = %v, wantErr %v", tt.item, err, tt.wantErr)
+			continue
+		}
+		if gotType != tt.wantType || got != tt.want {
+			t.Errorf("classifyItem(%q) = %q, %q, want %q, %q", tt.item, gotType, got, tt.wantType, tt.want)
+		}
+	}
+}
+
+func TestItemGroups(t *testing.T) {
+	items := itemGroups{}
+	items.add("tag", "a")
+	items.add("url", "https://cdn.example.com/a.js", "https://cdn.example.com/b.js")
+	items.add("cpcode", "1")
+	items.add("directory")
+
+	if got := items.count(); got != 4 {
+		t.Errorf("count() = %d, want 4", got)
+	}
+	// 按contentTypeOrder排序，跳过空的组
+	if got, want := items.types(), []string{"url", "cpcode", "tag"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("types() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadItemsFromFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    itemGroups
+		wantErr string
+	}{
+		{
+			name: "mixed",
+			content: `# 混合内容
+https://cdn.example.com/a.js
+12345
+/static/css/
+
+tag:release-1
+https://cdn.example.com/b.js
+`,
+			want: itemGroups{
+				"url":       {"https://cdn.example.com/a.js", "https://cdn.example.com/b.js"},
+				"cpcode":    {"12345"},
+				"directory": {"/static/css/"},
+				"tag":       {"release-1"},
+			},
+		},
+		{name: "only comments", content: "# nothing\n\n", want: itemGroups{}},
+		{name: "unknown line", content: "https://cdn.example.com/a.js\nbad line\n", wantErr: "第2行"},
+		{name: "invalid tag", content: "tag:a,b\n", wantErr: "第1行"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "items.txt")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := loadItemsFromFile(path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: loadItemsFromFile() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPreparePurgeGroup(t *testing.T) {
+	config := &AkamaiConfig{BaseURL: "https://akab.example.net", Network: "staging", CDNDomain: "https://cdn.example.com/"}
+	tests := []struct {
+		contentType string
+		refreshType string
+		items       []string
+		wantURL     string
+		wantObjects []string
+	}{
+		{"url", "delete", []string{"https://cdn.example.com/a.js"}, "https://akab.example.net/ccu/v3/invalidate/url/staging", []string{"https://cdn.example.com/a.js"}},
+		{"directory", "remove", []string{"/static/"}, "https://akab.example.net/ccu/v3/delete/url/staging", []string{"https://cdn.example.com/static/"}},
+		{"cpcode", "delete", []string{"123"}, "https://akab.example.net/ccu/v3/invalidate/cpcode/staging", []string{"123"}},
+		{"tag", "delete", []string{"a", "a", "b"}, "https://akab.example.net/ccu/v3/invalidate/tag/staging", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		group, err := preparePurgeGroup(config, tt.items, tt.refreshType, tt.contentType)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.contentType, err)
+			continue
+		}
+		if group.APIURL != tt.wantURL || !reflect.DeepEqual(group.Objects, tt.wantObjects) {
+			t.Errorf("%s: got %s %q, want %s %q", tt.contentType, group.APIURL, group.Objects, tt.wantURL, tt.wantObjects)
+		}
+		if len(group.Batches) != 1 {
+			t.Errorf("%s: got %d batches, want 1", tt.contentType, len(group.Batches))
+		}
+	}
+
+	// 目录刷新需要CDN_DOMAIN
+	if _, err := preparePurgeGroup(&AkamaiConfig{}, []string{"/static/"}, "delete", "directory"); err == nil {
+		t.Errorf("directory without CDN_DOMAIN: want error")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetries   = 4                // 失败后最多重试次数
+	defaultRateLimit = 10               // 每秒最多请求数
+	rateLimitBurst   = 20               // 令牌桶容量
+	retryBaseDelay   = 1 * time.Second  // 第一次重试的基础等待时间，之后每次翻倍
+	maxRetryDelay    = 30 * time.Second // 指数退避的上限
+	maxRetryAfter    = 2 * time.Minute  // 服务端要求的等待时间上限
+)
+
+// 可以重试的失败: 网络错误、5xx、429
+type retryableError struct {
+	Err        error
+	RetryAfter time.Duration // 服务端通过Retry-After等响应头要求的等待时间
+}
+
+func (e *retryableError) Error() string { return e.Err.Error() }
+func (e *retryableError) Unwrap() error { return e.Err }
+
+// 从响应头读取服务端要求的等待时间: Retry-After (秒数或HTTP日期)，其次Akamai的X-RateLimit-Next
+func retryAfterFromHeader(header http.Header) time.Duration {
+	var wait time.Duration
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if t, err := http.ParseTime(value); err == nil {
+			wait = time.Until(t)
+		}
+	} else if value := header.Get("X-RateLimit-Next"); value != "" {
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			wait = time.Until(t)
+		}
+	}
+	if wait < 0 {
+		return 0
+	}
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return wait
+}
+
+// 指数退避加随机抖动，避免多个批次同时重试
+func backoffDelay(attempt int) time.Duration {
+	delay := time.Duration(float64(retryBaseDelay) * math.Pow(2, float64(attempt-1)))
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// 客户端令牌桶，所有批次共用，避免触发Fast Purge的限流
+// 默认值偏保守，可以用 --rate-limit 按账号的实际限额调整
+type tokenBucket struct {
+	mutex    sync.Mutex
+	rate     float64 // 每秒补充的令牌数
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(rate float64, capacity int) *tokenBucket {
+	return &tokenBucket{rate: rate, capacity: float64(capacity), tokens: float64(capacity), last: time.Now()}
+}
+
+// 取一个令牌，没有时等待
+func (b *tokenBucket) wait() {
+	b.mutex.Lock()
+	now := time.Now()
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mutex.Unlock()
+	time.Sleep(delay)
+}
+
+// 提交一批请求，可重试的失败按退避时间重试
+// 每次重试都重新生成EdgeGrid签名，因为签名包含时间戳和nonce
+func sendPurgeRequestWithRetry(config *AkamaiConfig, apiURL string, batch purgeBatch, limiter *tokenBucket) (*AkamaiResponse, int, error) {
+	for attempt := 1; ; attempt++ {
+		limiter.wait()
+		response, err := sendPurgeRequest(config, apiURL, batch)
+
+		var retryErr *retryableError
+		if err == nil || !errors.As(err, &retryErr) || attempt > config.Retries {
+			if err != nil && attempt > 1 {
+				err = fmt.Errorf("%w (共尝试 %d 次)", err, attempt)
+			}
+			return response, attempt, err
+		}
+
+		delay := backoffDelay(attempt)
+		reason := ""
+		if retryErr.RetryAfter > delay {
+			delay = retryErr.RetryAfter
+			reason = "，按服务端要求等待"
+		}
+		fmt.Printf("🔁 %s%v，%.1f 秒后第 %d/%d 次重试%s\n", batch.label(), retryErr.Err, delay.Seconds(), attempt, config.Retries, reason)
+		time.Sleep(delay)
+	}
+}
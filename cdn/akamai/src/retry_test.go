@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfterFromHeader(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		headers map[string]string
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"none", nil, 0, 0},
+		{"seconds", map[string]string{"Retry-After": "5"}, 5 * time.Second, 5 * time.Second},
+		{"http date", map[string]string{"Retry-After": now.Add(10 * time.Second).UTC().Format(http.TimeFormat)}, 8 * time.Second, 10 * time.Second},
+		{"past date", map[string]string{"Retry-After": now.Add(-time.Hour).UTC().Format(http.TimeFormat)}, 0, 0},
+		{"capped", map[string]string{"Retry-After": "3600"}, maxRetryAfter, maxRetryAfter},
+		{"invalid", map[string]string{"Retry-After": "soon"}, 0, 0},
+		{"rate limit next", map[string]string{"X-RateLimit-Next": now.Add(3 * time.Second).UTC().Format(time.RFC3339Nano)}, 2 * time.Second, 3 * time.Second},
+		// Retry-After优先于X-RateLimit-Next
+		{"both", map[string]string{"Retry-After": "1", "X-RateLimit-Next": now.Add(time.Minute).UTC().Format(time.RFC3339Nano)}, time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		for key, value := range tt.headers {
+			header.Set(key, value)
+		}
+		got := retryAfterFromHeader(header)
+		if got < tt.min || got > tt.max {
+			t.Errorf("%s: retryAfterFromHeader() = %v, want between %v and %v", tt.name, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		base    time.Duration // 抖动前的等待时间，结果在[base/2, base]之间
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, maxRetryDelay},
+		{20, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			got := backoffDelay(tt.attempt)
+			if got < tt.base/2 || got > tt.base {
+				t.Errorf("backoffDelay(%d) = %v, want between %v and %v", tt.attempt, got, tt.base/2, tt.base)
+				break
+			}
+		}
+	}
+}
+
+func TestRetryableErrorUnwrap(t *testing.T) {
+	cause := fmt.Errorf("HTTP 503")
+	var err error = fmt.Errorf("批次失败: %w", &retryableError{Err: cause, RetryAfter: time.Second})
+
+	var retryErr *retryableError
+	if !errors.As(err, &retryErr) || retryErr.RetryAfter != time.Second {
+		t.Errorf("errors.As did not find the retryableError")
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("retryableError does not unwrap to its cause")
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	// 容量内的请求不等待，超出后按速率等待
+	bucket := newTokenBucket(10, 3)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		bucket.wait()
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("burst of 3 took %v, want no wait", elapsed)
+	}
+	bucket.wait()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("request over the burst took %v, want about 100ms", elapsed)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	maxTagLength      = 128  // 单个cache tag最长128个字符
+	maxTagsPerRequest = 5000 // 单次请求最多5000个tag，超过时分批
+	tagFilePrefix     = "tag:"
+)
+
+// cache tag允许的字符: 字母、数字和 !#$%&'+-.^_`|~ (与Edge-Cache-Tag响应头一致)
+func isTagChar(c rune) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.ContainsRune("!#$%&'+-.^_`|~", c)
+}
+
+func validateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag为空")
+	}
+	if len(tag) > maxTagLength {
+		return fmt.Errorf("tag超过%d个字符: %s", maxTagLength, tag)
+	}
+	for _, c := range tag {
+		if !isTagChar(c) {
+			return fmt.Errorf("tag包含不允许的字符 %q: %s", c, tag)
+		}
+	}
+	return nil
+}
+
+// 检查并去重，保持原有顺序；tag区分大小写
+func normalizeTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	var result []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if err := validateTag(tag); err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result, nil
+}
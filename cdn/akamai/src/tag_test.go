@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		wantErr string
+	}{
+		{"game-v1.2.0", ""},
+		{"A_b.c~d|e", ""},
+		{"!#$%&'+-.^_`|~", ""},
+		{strings.Repeat("a", maxTagLength), ""},
+		{"", "tag为空"},
+		{strings.Repeat("a", maxTagLength+1), "超过128个字符"},
+		{"has space", "不允许的字符"},
+		{"a,b", "不允许的字符"},
+		{"tag:name", "不允许的字符"},
+		{"中文", "不允许的字符"},
+	}
+	for _, tt := range tests {
+		err := validateTag(tt.tag)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("validateTag(%q) error = %v", tt.tag, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("validateTag(%q) error = %v, want containing %q", tt.tag, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{[]string{"b", " a ", "b", "A"}, []string{"b", "a", "A"}, false},
+		{[]string{"ok", "not ok"}, nil, true},
+		{nil, nil, false},
+	}
+	for _, tt := range tests {
+		got, err := normalizeTags(tt.tags)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeTags(%q) error = %v, wantErr %v", tt.tags, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("normalizeTags(%q) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+}
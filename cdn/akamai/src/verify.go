@@ -0,0 +1,287 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultVerifyTimeout = 10 * time.Minute // 验证的截止时间，从提交刷新开始计算
+	verifyInterval       = 15 * time.Second // 未通过的URL再次检查的间隔
+	verifyConcurrency    = 8
+)
+
+// Akamai pragma调试头，让边缘节点返回缓存状态
+const akamaiPragma = "akamai-x-cache-on, akamai-x-cache-remote-on, akamai-x-get-cache-key, akamai-x-check-cacheable"
+
+// 一次探测的结果
+type probeResult struct {
+	Status       int
+	Age          int // 没有Age头时为-1
+	ETag         string
+	LastModified string
+	Hash         string // 内容SHA-256，只在需要比较内容时计算
+	XCache       string // 使用pragma头时边缘返回的X-Cache
+}
+
+// 一个URL的验证状态
+type verifyState struct {
+	URL      string
+	Passed   bool
+	Reason   string
+	Attempts int
+}
+
+// 等到预计生效时间后探测已刷新的URL，直到全部通过或超过截止时间
+func verifyPurge(config *AkamaiConfig, results []batchResult, contentType string, submitted time.Time) error {
+	if contentType == "cpcode" || contentType == "tag" {
+		fmt.Printf("⚠️  %s刷新没有具体的URL，跳过验证\n", contentType)
+		return nil
+	}
+
+	var urls []string
+	maxSeconds := 0
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		for _, object := range result.Batch.Objects {
+			// 目录只是路径前缀，无法逐个验证
+			if !strings.HasSuffix(object, "/") {
+				urls = append(urls, object)
+			}
+		}
+		if result.Response.EstimatedSeconds > maxSeconds {
+			maxSeconds = result.Response.EstimatedSeconds
+		}
+	}
+	if len(urls) == 0 {
+		fmt.Printf("⚠️  没有可以验证的URL (目录刷新无法逐个验证)\n")
+		return nil
+	}
+
+	deadline := submitted.Add(config.VerifyTimeout)
+	effective := submitted.Add(time.Duration(maxSeconds) * time.Second)
+	// 预计生效时间晚于截止时间时只等到截止时间，检查一次，未通过的标为暂无法验证
+	notYet := effective.After(deadline)
+	if notYet {
+		fmt.Printf("\n⚠️  预计生效时间 %d 秒超过验证时限 %s，截止时检查一次\n", maxSeconds, config.VerifyTimeout)
+		effective = deadline
+	}
+	if wait := time.Until(effective); wait > 0 {
+		fmt.Printf("\n⏳ 等待 %.0f 秒后开始验证...\n", wait.Seconds())
+		time.Sleep(wait)
+	}
+
+	fmt.Printf("\n🔎 验证 %d 个URL (截止 %s", len(urls), deadline.Format("15:04:05"))
+	if config.OriginDomain != "" {
+		fmt.Printf("，与源站 %s 对比", config.OriginDomain)
+	}
+	fmt.Printf(")\n")
+
+	states := make([]*verifyState, len(urls))
+	for i, u := range urls {
+		states[i] = &verifyState{URL: u}
+	}
+
+	for round := 1; ; round++ {
+		var pending []*verifyState
+		for _, state := range states {
+			if !state.Passed {
+				pending = append(pending, state)
+			}
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, verifyConcurrency)
+		for _, state := range pending {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(state *verifyState) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				state.Attempts++
+				state.Passed, state.Reason = verifyURL(config, state.URL, submitted)
+			}(state)
+		}
+		wg.Wait()
+
+		failed := 0
+		for _, state := range states {
+			if !state.Passed {
+				failed++
+			}
+		}
+		fmt.Printf("🔎 第 %d 轮: 通过 %d/%d\n", round, len(states)-failed, len(states))
+		if failed == 0 || notYet || time.Now().Add(verifyInterval).After(deadline) {
+			break
+		}
+		time.Sleep(verifyInterval)
+	}
+
+	if notYet {
+		for _, state := range states {
+			if !state.Passed {
+				state.Reason = "暂无法验证，未到预计生效时间: " + state.Reason
+			}
+		}
+	}
+	err := printVerifyTable(states)
+	if err != nil && notYet {
+		return fmt.Errorf("%v (未到预计生效时间 %d 秒，可调大 --verify-timeout)", err, maxSeconds)
+	}
+	return err
+}
+
+// 检查边缘返回的是否是刷新后的内容
+func verifyURL(config *AkamaiConfig, edgeURL string, submitted time.Time) (bool, string) {
+	edge, err := probe(edgeURL, "HEAD", config.Pragma)
+	if err != nil {
+		return false, err.Error()
+	}
+	if edge.Status >= 400 {
+		return false, fmt.Sprintf("边缘返回HTTP %d", edge.Status)
+	}
+
+	if config.OriginDomain != "" {
+		originURL, err := toOriginURL(edgeURL, config.OriginDomain)
+		if err != nil {
+			return false, err.Error()
+		}
+		origin, err := probe(originURL, "HEAD", false)
+		if err != nil {
+			return false, "源站: " + err.Error()
+		}
+		if origin.Status >= 400 {
+			return false, fmt.Sprintf("源站返回HTTP %d", origin.Status)
+		}
+
+		switch {
+		case edge.ETag != "" && origin.ETag != "":
+			if edge.ETag == origin.ETag {
+				return true, "ETag一致"
+			}
+			return false, fmt.Sprintf("ETag不一致: 边缘 %s，源站 %s", edge.ETag, origin.ETag)
+		case edge.LastModified != "" && origin.LastModified != "":
+			if edge.LastModified == origin.LastModified {
+				return true, "Last-Modified一致"
+			}
+			return false, fmt.Sprintf("Last-Modified不一致: 边缘 %s，源站 %s", edge.LastModified, origin.LastModified)
+		}
+
+		// 没有可比较的响应头时比较内容
+		if edge, err = probe(edgeURL, "GET", config.Pragma); err != nil {
+			return false, err.Error()
+		}
+		if origin, err = probe(originURL, "GET", false); err != nil {
+			return false, "源站: " + err.Error()
+		}
+		if edge.Hash == origin.Hash {
+			return true, "内容一致"
+		}
+		return false, "内容与源站不一致"
+	}
+
+	// 没有源站时，按缓存时间判断是否是刷新后重新回源的内容
+	elapsed := int(time.Since(submitted).Seconds())
+	if edge.Age >= 0 {
+		if edge.Age <= elapsed {
+			return true, fmt.Sprintf("Age %d 秒，刷新后已重新缓存", edge.Age)
+		}
+		return false, fmt.Sprintf("Age %d 秒，早于刷新时间", edge.Age)
+	}
+	if strings.Contains(edge.XCache, "MISS") {
+		return true, "X-Cache: " + edge.XCache
+	}
+	if edge.XCache != "" {
+		return false, "X-Cache: " + edge.XCache
+	}
+	return false, "没有Age头，无法判断 (可设置ORIGIN_DOMAIN或使用--pragma)"
+}
+
+func probe(rawURL, method string, pragma bool) (*probeResult, error) {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	if pragma {
+		req.Header.Set("Pragma", akamaiPragma)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	result := &probeResult{
+		Status:       resp.StatusCode,
+		Age:          -1,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		XCache:       resp.Header.Get("X-Cache"),
+	}
+	if age, err := strconv.Atoi(resp.Header.Get("Age")); err == nil {
+		result.Age = age
+	}
+	if method == "GET" {
+		hash := sha256.New()
+		if _, err := io.Copy(hash, resp.Body); err != nil {
+			return nil, fmt.Errorf("读取响应失败: %v", err)
+		}
+		result.Hash = hex.EncodeToString(hash.Sum(nil))
+	}
+	return result, nil
+}
+
+// 把CDN URL的协议和域名替换为源站
+func toOriginURL(edgeURL, originDomain string) (string, error) {
+	edge, err := url.Parse(edgeURL)
+	if err != nil {
+		return "", fmt.Errorf("无效的URL: %s", edgeURL)
+	}
+	origin, err := url.Parse(strings.TrimSuffix(originDomain, "/"))
+	if err != nil || origin.Host == "" {
+		return "", fmt.Errorf("无效的ORIGIN_DOMAIN: %s", originDomain)
+	}
+	edge.Scheme = origin.Scheme
+	edge.Host = origin.Host
+	edge.Path = origin.Path + edge.Path
+	return edge.String(), nil
+}
+
+func printVerifyTable(states []*verifyState) error {
+	width := 0
+	for _, state := range states {
+		if len(state.URL) > width {
+			width = len(state.URL)
+		}
+	}
+
+	failed := 0
+	fmt.Printf("\n📋 验证结果:\n")
+	fmt.Printf("     %-*s  次数  依据\n", width, "URL")
+	for _, state := range states {
+		mark := "✅"
+		if !state.Passed {
+			mark = "❌"
+			failed++
+		}
+		fmt.Printf("  %s %-*s  %4d  %s\n", mark, width, state.URL, state.Attempts, state.Reason)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("验证未通过: %d/%d 个URL", failed, len(states))
+	}
+	fmt.Printf("✅ 全部 %d 个URL验证通过\n", len(states))
+	return nil
+}
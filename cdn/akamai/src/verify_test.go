@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToOriginURL(t *testing.T) {
+	tests := []struct {
+		edge    string
+		origin  string
+		want    string
+		wantErr bool
+	}{
+		{"https://cdn.example.com/a/b.js", "https://origin.example.com", "https://origin.example.com/a/b.js", false},
+		{"https://cdn.example.com/a/b.js?v=1", "http://origin.example.com:8080/", "http://origin.example.com:8080/a/b.js?v=1", false},
+		{"https://cdn.example.com/b.js", "https://origin.example.com/static", "https://origin.example.com/static/b.js", false},
+		{"https://cdn.example.com/b.js", "origin.example.com", "", true},
+	}
+	for _, tt := range tests {
+		got, err := toOriginURL(tt.edge, tt.origin)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("toOriginURL(%q, %q) error = %v, wantErr %v", tt.edge, tt.origin, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("toOriginURL(%q, %q) = %q, want %q", tt.edge, tt.origin, got, tt.want)
+		}
+	}
+}
+
+// 按给定的响应头和内容返回的测试服务
+func newVerifyServer(headers map[string]string, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, value := range headers {
+			w.Header().Set(key, value)
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func TestVerifyURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		edge       map[string]string
+		edgeBody   string
+		origin     map[string]string // nil表示不与源站对比
+		originBody string
+		wantPassed bool
+		wantReason string
+	}{
+		{"etag match", map[string]string{"ETag": `"v2"`}, "", map[string]string{"ETag": `"v2"`}, "", true, "ETag一致"},
+		{"etag differs", map[string]string{"ETag": `"v1"`}, "", map[string]string{"ETag": `"v2"`}, "", false, "ETag不一致"},
+		{"last-modified", map[string]string{"Last-Modified": "Mon, 01 Jan 2024 00:00:00 GMT"}, "",
+			map[string]string{"Last-Modified": "Mon, 01 Jan 2024 00:00:00 GMT"}, "", true, "Last-Modified一致"},
+		{"content match", map[string]string{}, "new", map[string]string{}, "new", true, "内容一致"},
+		{"content differs", map[string]string{}, "old", map[string]string{}, "new", false, "内容与源站不一致"},
+		{"fresh age", map[string]string{"Age": "0"}, "", nil, "", true, "刷新后已重新缓存"},
+		{"stale age", map[string]string{"Age": "86400"}, "", nil, "", false, "早于刷新时间"},
+		{"x-cache miss", map[string]string{"X-Cache": "TCP_MISS from a1"}, "", nil, "", true, "X-Cache"},
+		{"no hints", map[string]string{}, "", nil, "", false, "无法判断"},
+	}
+	for _, tt := range tests {
+		edge := newVerifyServer(tt.edge, tt.edgeBody)
+		config := &AkamaiConfig{}
+		var origin *httptest.Server
+		if tt.origin != nil {
+			origin = newVerifyServer(tt.origin, tt.originBody)
+			config.OriginDomain = origin.URL
+		}
+
+		passed, reason := verifyURL(config, edge.URL+"/app.js", time.Now().Add(-time.Minute))
+		if passed != tt.wantPassed || !strings.Contains(reason, tt.wantReason) {
+			t.Errorf("%s: verifyURL() = %v, %q, want %v, containing %q", tt.name, passed, reason, tt.wantPassed, tt.wantReason)
+		}
+
+		edge.Close()
+		if origin != nil {
+			origin.Close()
+		}
+	}
+}
@@ -282,7 +282,7 @@ for target in "${platforms_to_build[@]}"; do
             ;;
     esac
     
-    env GOOS=$os GOARCH=$arch CGO_ENABLED=0 go build -ldflags="-s -w" -o "$output_path" oss_ultra_fast.go
+    env GOOS=$os GOARCH=$arch CGO_ENABLED=0 go build -ldflags="-s -w" -o "$output_path" .
     if [ $? -eq 0 ]; then
         size=$(du -h "$output_path" | cut -f1)
         echo "   [OK] Success: $output_name ($size)"
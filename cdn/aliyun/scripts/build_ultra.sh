@@ -37,7 +37,7 @@ rm -f "$PROJECT_ROOT/oss_ultra_fast" "$PROJECT_ROOT/oss_ultra_fast.exe"
 
 echo ""
 echo "构建极速版本..."
-if go build -o "$DIST_DIR/oss_ultra_fast" oss_ultra_fast.go; then
+if go build -o "$DIST_DIR/oss_ultra_fast" .; then
     echo "✅ 构建成功!"
     
     if [ -f "$DIST_DIR/oss_ultra_fast" ]; then
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCDNBaseURL      = "https://cdn-mh.hwrescdn.com" // oss-mh bucket的默认CDN域名
+	defaultAliyunCDNAPI    = "https://cdn.aliyuncs.com"
+	akamaiMaxBodyBytes     = 50000           // Fast Purge请求体上限
+	cdnMaxRetryAfter       = 2 * time.Minute // 服务端要求的等待时间上限
+	aliyunCDNMaxBatchItems = 1000            // RefreshObjectCaches单次最多1000条URL
+)
+
+// CDN刷新结果
+type cdnRefreshResult struct {
+	Provider string
+	Batches  int
+	URLs     int
+	TaskIDs  []string
+	Failed   []error
+}
+
+// 由CDN基础地址和对象路径拼出CDN URL
+func cdnURL(config *UltraConfig, key string) string {
+	base := config.CDNBaseURL
+	if base == "" {
+		return ""
+	}
+	segments := strings.Split(strings.TrimPrefix(key, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.Join(segments, "/")
+}
+
+// 未配置CDN地址时，只有oss-mh沿用原来的默认CDN域名
+func resolveCDNBaseURL(config *UltraConfig) {
+	if config.CDNBaseURL == "" {
+		config.CDNBaseURL = os.Getenv("OSS_CDN_BASE_URL")
+	}
+	if config.CDNBaseURL == "" && config.BucketName == "oss-mh" {
+		config.CDNBaseURL = defaultCDNBaseURL
+	}
+}
+
+// 按数量和JSON数组的大小切分URL，maxItems或maxBytes为0时不限制
+func batchCDNURLs(urls []string, maxItems, maxBytes int) [][]string {
+	var batches [][]string
+	var current []string
+	size := 0
+	for _, u := range urls {
+		// 按JSON编码后的长度计算 (&等字符会被转义)，加上分隔的逗号
+		encoded, _ := json.Marshal(u)
+		itemSize := len(encoded) + 1
+		if len(current) > 0 && ((maxItems > 0 && len(current) >= maxItems) || (maxBytes > 0 && size+itemSize > maxBytes)) {
+			batches = append(batches, current)
+			current = nil
+			size = 0
+		}
+		current = append(current, u)
+		size += itemSize
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// 上传完成后刷新本次上传的对象
+func refreshCDNAfterUpload(config *UltraConfig) error {
+	if config.RefreshProvider == "" || len(config.UploadedKeys) == 0 {
+		return nil
+	}
+
+	urls := make([]string, 0, len(config.UploadedKeys))
+	for _, key := range config.UploadedKeys {
+		urls = append(urls, cdnURL(config, key))
+	}
+
+	fmt.Printf("\n🌐 刷新CDN缓存 (%s): %d 个URL\n", config.RefreshProvider, len(urls))
+
+	var result *cdnRefreshResult
+	var err error
+	switch config.RefreshProvider {
+	case "akamai":
+		result, err = refreshAkamai(config, urls)
+	case "aliyun":
+		result, err = refreshAliyunCDN(config, urls)
+	default:
+		return fmt.Errorf("不支持的CDN刷新方式: %s", config.RefreshProvider)
+	}
+	if err != nil {
+		return fmt.Errorf("CDN刷新失败: %v", err)
+	}
+
+	fmt.Printf("✅ CDN刷新提交完成: %d 批, %d 个URL\n", result.Batches, result.URLs)
+	for _, id := range result.TaskIDs {
+		fmt.Printf("🆔 任务ID: %s\n", id)
+	}
+	if len(result.Failed) > 0 {
+		for _, failure := range result.Failed {
+			fmt.Printf("❌ %v\n", failure)
+		}
+		return fmt.Errorf("CDN刷新失败: %d/%d 批未成功", len(result.Failed), result.Batches)
+	}
+	return nil
+}
+
+// Akamai凭证和刷新设置，优先环境变量，其次akamai.conf格式的配置文件
+type akamaiCredentials struct {
+	ClientToken  string
+	ClientSecret string
+	AccessToken  string
+	BaseURL      string
+	Network      string // staging 或 production，同akamai工具的AKAMAI_NETWORK
+	RefreshType  string // delete (invalidate) 或 remove，同akamai工具的DEFAULT_REFRESH_TYPE
+}
+
+func loadAkamaiCredentials(confFile string) (*akamaiCredentials, error) {
+	creds := &akamaiCredentials{
+		ClientToken:  os.Getenv("AKAMAI_CLIENT_TOKEN"),
+		ClientSecret: os.Getenv("AKAMAI_CLIENT_SECRET"),
+		AccessToken:  os.Getenv("AKAMAI_ACCESS_TOKEN"),
+		BaseURL:      os.Getenv("AKAMAI_BASE_URL"),
+		Network:      os.Getenv("AKAMAI_NETWORK"),
+		RefreshType:  os.Getenv("DEFAULT_REFRESH_TYPE"),
+	}
+
+	if confFile != "" {
+		content, err := os.ReadFile(confFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取Akamai配置失败: %v", err)
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") || !strings.Contains(line, "=") {
+				continue
+			}
+			parts := strings.SplitN(line, "=", 2)
+			key := strings.TrimSpace(parts[0])
+			value := strings.Trim(strings.TrimSpace(parts[1]), "\"'")
+			switch {
+			case key == "AKAMAI_CLIENT_TOKEN" && creds.ClientToken == "":
+				creds.ClientToken = value
+			case key == "AKAMAI_CLIENT_SECRET" && creds.ClientSecret == "":
+				creds.ClientSecret = value
+			case key == "AKAMAI_ACCESS_TOKEN" && creds.AccessToken == "":
+				creds.AccessToken = value
+			case key == "AKAMAI_BASE_URL" && creds.BaseURL == "":
+				creds.BaseURL = value
+			case key == "AKAMAI_NETWORK" && creds.Network == "":
+				creds.Network = value
+			case key == "DEFAULT_REFRESH_TYPE" && creds.RefreshType == "":
+				creds.RefreshType = value
+			}
+		}
+	}
+
+	if creds.ClientToken == "" || creds.ClientSecret == "" || creds.AccessToken == "" || creds.BaseURL == "" {
+		return nil, fmt.Errorf("缺少Akamai凭证，请设置AKAMAI_CLIENT_TOKEN、AKAMAI_CLIENT_SECRET、AKAMAI_ACCESS_TOKEN、AKAMAI_BASE_URL或使用 --akamai-conf")
+	}
+	if !strings.HasPrefix(creds.BaseURL, "http") {
+		creds.BaseURL = "https://" + creds.BaseURL
+	}
+	if creds.Network == "" {
+		creds.Network = "production"
+	}
+	if creds.Network != "staging" && creds.Network != "production" {
+		return nil, fmt.Errorf("AKAMAI_NETWORK 必须是 staging 或 production: %s", creds.Network)
+	}
+	if creds.RefreshType == "" {
+		creds.RefreshType = "delete"
+	}
+	if creds.RefreshType != "delete" && creds.RefreshType != "remove" {
+		return nil, fmt.Errorf("DEFAULT_REFRESH_TYPE 必须是 delete 或 remove: %s", creds.RefreshType)
+	}
+	return creds, nil
+}
+
+// CCU v3 URL刷新接口，与akamai工具的purgeEndpoint一致: remove 对应 delete 接口，否则 invalidate
+func akamaiPurgePath(creds *akamaiCredentials) string {
+	action := "invalidate"
+	if creds.RefreshType == "remove" {
+		action = "delete"
+	}
+	return fmt.Sprintf("/ccu/v3/%s/url/%s", action, creds.Network)
+}
+
+// CDN刷新接口返回的可重试失败 (5xx、429、限流)
+type cdnRetryableError struct {
+	Status     int
+	Body       string
+	RetryAfter time.Duration // Retry-After或X-RateLimit-Next要求的等待时间
+}
+
+func (e *cdnRetryableError) Error() string {
+	return fmt.Sprintf("HTTP %d - %s", e.Status, e.Body)
+}
+
+// 从响应头读取服务端要求的等待时间: Retry-After (秒数或HTTP日期)，其次Akamai的X-RateLimit-Next
+func cdnRetryAfter(header http.Header) time.Duration {
+	var wait time.Duration
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if t, err := http.ParseTime(value); err == nil {
+			wait = time.Until(t)
+		}
+	} else if value := header.Get("X-RateLimit-Next"); value != "" {
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			wait = time.Until(t)
+		}
+	}
+	if wait < 0 {
+		return 0
+	}
+	if wait > cdnMaxRetryAfter {
+		return cdnMaxRetryAfter
+	}
+	return wait
+}
+
+func refreshAkamai(config *UltraConfig, urls []string) (*cdnRefreshResult, error) {
+	creds, err := loadAkamaiCredentials(config.AkamaiConfFile)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := strings.TrimSuffix(creds.BaseURL, "/") + akamaiPurgePath(creds)
+	parsed, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析Akamai Base URL失败: %v", err)
+	}
+	fmt.Printf("📡 Akamai网络: %s, 接口: %s\n", creds.Network, akamaiPurgePath(creds))
+
+	// 请求体上限减去 {"objects":[]} 本身的长度
+	empty, _ := json.Marshal(map[string]interface{}{"objects": []string{}})
+	result := &cdnRefreshResult{Provider: "akamai"}
+	client := &http.Client{Timeout: 30 * time.Second}
+	for i, batch := range batchCDNURLs(urls, 0, akamaiMaxBodyBytes-len(empty)) {
+		result.Batches++
+		body, err := json.Marshal(map[string]interface{}{"objects": batch})
+		if err != nil {
+			return nil, err
+		}
+
+		var respBody []byte
+		err = retryCDNBatch(config, i+1, func() error {
+			var err error
+			respBody, err = sendAkamaiPurge(client, creds, apiURL, parsed, body)
+			return err
+		})
+		if err != nil {
+			result.Failed = append(result.Failed, fmt.Errorf("第%d批刷新失败: %v", i+1, err))
+			continue
+		}
+
+		var purge struct {
+			PurgeID          string `json:"purgeId"`
+			EstimatedSeconds int    `json:"estimatedSeconds"`
+		}
+		if err := json.Unmarshal(respBody, &purge); err != nil {
+			result.Failed = append(result.Failed, fmt.Errorf("第%d批响应无法解析: %v - %s", i+1, err, string(respBody)))
+			continue
+		}
+		result.URLs += len(batch)
+		if purge.PurgeID != "" {
+			result.TaskIDs = append(result.TaskIDs, purge.PurgeID)
+		}
+		fmt.Printf("📤 第%d批: %d 个URL, 预计%d秒生效\n", i+1, len(batch), purge.EstimatedSeconds)
+	}
+	return result, nil
+}
+
+// 提交一批刷新请求，5xx、429和网络错误按 --retries 重试，优先使用服务端要求的等待时间
+// 签名包含时间戳和nonce，send每次调用都要重新签名
+func retryCDNBatch(config *UltraConfig, batch int, send func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := send()
+		var retryErr *cdnRetryableError
+		retryable := errors.As(err, &retryErr)
+		if !retryable && err != nil {
+			_, retryable = retryableCause(err)
+		}
+		if err == nil || !retryable || attempt >= config.Network.Retries || config.Interrupt.Stopped() {
+			return err
+		}
+
+		delay := retryDelay(attempt + 1)
+		if retryErr != nil && retryErr.RetryAfter > delay {
+			delay = retryErr.RetryAfter
+		}
+		fmt.Printf("🔁 第%d批刷新失败 (%v)，%.1f秒后第%d/%d次重试\n", batch, err, delay.Seconds(), attempt+1, config.Network.Retries)
+		if !config.Interrupt.Sleep(delay) {
+			return err
+		}
+	}
+}
+
+// 签名并提交一批Fast Purge请求，5xx和429返回cdnRetryableError
+func sendAkamaiPurge(client *http.Client, creds *akamaiCredentials, apiURL string, parsed *url.URL, body []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", edgeGridAuthorization(creds, "POST", parsed, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &cdnRetryableError{Status: resp.StatusCode, Body: string(respBody), RetryAfter: cdnRetryAfter(resp.Header)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d - %s", resp.StatusCode, string(respBody))
+	}
+	return respBody, nil
+}
+
+// EdgeGrid签名，算法与akamai_cdn_refresh.go一致
+func edgeGridAuthorization(creds *akamaiCredentials, method string, target *url.URL, body []byte) string {
+	timestamp := time.Now().UTC().Format("20060102T15:04:05+0000")
+	nonceBytes := make([]byte, 16)
+	rand.Read(nonceBytes)
+	nonce := fmt.Sprintf("%x-%x-%x-%x-%x", nonceBytes[0:4], nonceBytes[4:6], nonceBytes[6:8], nonceBytes[8:10], nonceBytes[10:])
+
+	msgPath := target.EscapedPath()
+	if target.RawQuery != "" {
+		msgPath += "?" + target.RawQuery
+	}
+
+	contentHash := ""
+	if method == "POST" && len(body) > 0 {
+		sum := sha256.Sum256(body)
+		contentHash = base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	authHeader := fmt.Sprintf("EG1-HMAC-SHA256 client_token=%s;access_token=%s;timestamp=%s;nonce=%s;",
+		creds.ClientToken, creds.AccessToken, timestamp, nonce)
+	msg := strings.Join([]string{method, target.Scheme, target.Host, msgPath, "", contentHash, authHeader}, "\t")
+
+	signingKey := hmacSHA256Base64(timestamp, creds.ClientSecret)
+	return authHeader + "signature=" + hmacSHA256Base64(msg, signingKey)
+}
+
+func hmacSHA256Base64(data, key string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func refreshAliyunCDN(config *UltraConfig, urls []string) (*cdnRefreshResult, error) {
+	endpoint := os.Getenv("ALIYUN_CDN_ENDPOINT")
+	if endpoint == "" {
+		endpoint = defaultAliyunCDNAPI
+	}
+
+	result := &cdnRefreshResult{Provider: "aliyun"}
+	client := &http.Client{Timeout: 30 * time.Second}
+	for i, batch := range batchCDNURLs(urls, aliyunCDNMaxBatchItems, 0) {
+		result.Batches++
+		params := map[string]string{
+			"Action":     "RefreshObjectCaches",
+			"ObjectPath": strings.Join(batch, "\n"),
+			"ObjectType": "File",
+		}
+
+		var taskID string
+		err := retryCDNBatch(config, i+1, func() error {
+			var err error
+			taskID, err = sendAliyunRefresh(client, endpoint, config, params)
+			return err
+		})
+		if err != nil {
+			result.Failed = append(result.Failed, fmt.Errorf("第%d批刷新失败: %v", i+1, err))
+			continue
+		}
+
+		result.URLs += len(batch)
+		result.TaskIDs = append(result.TaskIDs, taskID)
+		fmt.Printf("📤 第%d批: %d 个URL\n", i+1, len(batch))
+	}
+	return result, nil
+}
+
+// 签名并提交一批阿里云CDN刷新，返回任务ID；5xx、429和Throttling错误码返回cdnRetryableError
+func sendAliyunRefresh(client *http.Client, endpoint string, config *UltraConfig, params map[string]string) (string, error) {
+	resp, body, err := callAliyunRPC(client, endpoint, config.AccessKeyID, config.AccessKeySecret, params)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		RefreshTaskID string `json:"RefreshTaskId"`
+		RequestID     string `json:"RequestId"`
+		Code          string `json:"Code"`
+		Message       string `json:"Message"`
+	}
+	parseErr := json.Unmarshal(body, &response)
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || strings.HasPrefix(response.Code, "Throttling") {
+		return "", &cdnRetryableError{Status: resp.StatusCode, Body: string(body), RetryAfter: cdnRetryAfter(resp.Header)}
+	}
+	if parseErr != nil {
+		return "", fmt.Errorf("响应无法解析: HTTP %d - %s", resp.StatusCode, string(body))
+	}
+	if resp.StatusCode != http.StatusOK || response.Code != "" {
+		return "", fmt.Errorf("HTTP %d %s - %s", resp.StatusCode, response.Code, response.Message)
+	}
+	return response.RefreshTaskID, nil
+}
+
+// 调用阿里云RPC风格API (签名版本1.0)，每次调用使用新的nonce和时间戳
+// 返回的响应体已读取并关闭
+func callAliyunRPC(client *http.Client, endpoint, accessKeyID, accessKeySecret string, params map[string]string) (*http.Response, []byte, error) {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	all := map[string]string{
+		"Format":           "JSON",
+		"Version":          "2018-05-10",
+		"AccessKeyId":      accessKeyID,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureVersion": "1.0",
+		"SignatureNonce":   fmt.Sprintf("%x", nonce),
+		"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	for k, v := range params {
+		all[k] = v
+	}
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, aliyunPercentEncode(k)+"="+aliyunPercentEncode(all[k]))
+	}
+	canonicalized := strings.Join(pairs, "&")
+
+	stringToSign := "POST&%2F&" + aliyunPercentEncode(canonicalized)
+	mac := hmac.New(sha1.New, []byte(accessKeySecret+"&"))
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	form := canonicalized + "&Signature=" + aliyunPercentEncode(signature)
+	resp, err := client.Post(strings.TrimSuffix(endpoint, "/")+"/", "application/x-www-form-urlencoded", strings.NewReader(form))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp, body, err
+}
+
+func aliyunPercentEncode(s string) string {
+	encoded := url.QueryEscape(s)
+	encoded = strings.ReplaceAll(encoded, "+", "%20")
+	encoded = strings.ReplaceAll(encoded, "*", "%2A")
+	encoded = strings.ReplaceAll(encoded, "%7E", "~")
+	return encoded
+}
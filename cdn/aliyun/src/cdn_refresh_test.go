@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCDNURL(t *testing.T) {
+	tests := []struct {
+		base string
+		key  string
+		want string
+	}{
+		{"", "a.js", ""},
+		{"https://cdn.example.com", "static/a.js", "https://cdn.example.com/static/a.js"},
+		{"https://cdn.example.com/", "/static/a.js", "https://cdn.example.com/static/a.js"},
+		{"https://cdn.example.com", "dir/a b#1.js", "https://cdn.example.com/dir/a%20b%231.js"},
+		{"https://cdn.example.com", "图片/a.png", "https://cdn.example.com/%E5%9B%BE%E7%89%87/a.png"},
+	}
+	for _, tt := range tests {
+		config := &UltraConfig{CDNBaseURL: tt.base}
+		if got := cdnURL(config, tt.key); got != tt.want {
+			t.Errorf("cdnURL(%q, %q) = %q, want %q", tt.base, tt.key, got, tt.want)
+		}
+	}
+}
+
+func makeCDNURLs(n int, suffix string) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://cdn.example.com/%04d", i) + suffix
+	}
+	return urls
+}
+
+// JSON数组中元素和逗号的长度，不含方括号 (调用方已从上限中扣除)
+func jsonItemsSize(urls []string) int {
+	encoded, _ := json.Marshal(urls)
+	return len(encoded) - 2
+}
+
+func TestBatchCDNURLs(t *testing.T) {
+	tests := []struct {
+		name        string
+		urls        []string
+		maxItems    int
+		maxBytes    int
+		wantBatches int
+	}{
+		{"empty", nil, 100, 0, 0},
+		{"unlimited", makeCDNURLs(250, ""), 0, 0, 1},
+		{"item limit", makeCDNURLs(250, ""), 100, 0, 3},
+		{"exact item limit", makeCDNURLs(200, ""), 100, 0, 2},
+		// 每个URL编码后 "https://cdn.example.com/0000" 30字节，加逗号31字节
+		{"byte limit", makeCDNURLs(100, ""), 0, 31 * 10, 10},
+		// & 编码成 \u0026，每个URL按编码后的长度算92字节 (原始长度只有42字节)
+		{"escaped", makeCDNURLs(10, "?"+strings.Repeat("&", 10)), 0, 92 * 5, 2},
+		{"both limits", makeCDNURLs(100, ""), 8, 31 * 10, 13},
+		{"oversized url", []string{"https://cdn.example.com/" + strings.Repeat("a", 100)}, 0, 50, 1},
+	}
+	for _, tt := range tests {
+		batches := batchCDNURLs(tt.urls, tt.maxItems, tt.maxBytes)
+		if len(batches) != tt.wantBatches {
+			t.Errorf("%s: got %d batches, want %d", tt.name, len(batches), tt.wantBatches)
+		}
+
+		var all []string
+		for i, batch := range batches {
+			if tt.maxItems > 0 && len(batch) > tt.maxItems {
+				t.Errorf("%s: batch %d has %d urls, limit %d", tt.name, i, len(batch), tt.maxItems)
+			}
+			// 单个超长URL仍单独成批
+			if tt.maxBytes > 0 && len(batch) > 1 && jsonItemsSize(batch) > tt.maxBytes {
+				t.Errorf("%s: batch %d is %d bytes, limit %d", tt.name, i, jsonItemsSize(batch), tt.maxBytes)
+			}
+			all = append(all, batch...)
+		}
+		if !reflect.DeepEqual(all, tt.urls) {
+			t.Errorf("%s: batches do not keep all urls in order", tt.name)
+		}
+	}
+}
+
+func TestAkamaiPurgePath(t *testing.T) {
+	tests := []struct {
+		refreshType string
+		network     string
+		want        string
+	}{
+		{"delete", "production", "/ccu/v3/invalidate/url/production"},
+		{"remove", "production", "/ccu/v3/delete/url/production"},
+		{"delete", "staging", "/ccu/v3/invalidate/url/staging"},
+		{"remove", "staging", "/ccu/v3/delete/url/staging"},
+	}
+	for _, tt := range tests {
+		creds := &akamaiCredentials{RefreshType: tt.refreshType, Network: tt.network}
+		if got := akamaiPurgePath(creds); got != tt.want {
+			t.Errorf("akamaiPurgePath(%q, %q) = %q, want %q", tt.refreshType, tt.network, got, tt.want)
+		}
+	}
+}
+
+func TestCDNRetryAfter(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		headers map[string]string
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"none", nil, 0, 0},
+		{"seconds", map[string]string{"Retry-After": "5"}, 5 * time.Second, 5 * time.Second},
+		{"http date", map[string]string{"Retry-After": now.Add(10 * time.Second).UTC().Format(http.TimeFormat)}, 8 * time.Second, 10 * time.Second},
+		{"past date", map[string]string{"Retry-After": now.Add(-time.Hour).UTC().Format(http.TimeFormat)}, 0, 0},
+		{"capped", map[string]string{"Retry-After": "3600"}, cdnMaxRetryAfter, cdnMaxRetryAfter},
+		{"invalid", map[string]string{"Retry-After": "soon"}, 0, 0},
+		{"rate limit next", map[string]string{"X-RateLimit-Next": now.Add(3 * time.Second).UTC().Format(time.RFC3339Nano)}, 2 * time.Second, 3 * time.Second},
+		{"both", map[string]string{"Retry-After": "1", "X-RateLimit-Next": now.Add(time.Minute).UTC().Format(time.RFC3339Nano)}, time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		for key, value := range tt.headers {
+			header.Set(key, value)
+		}
+		if got := cdnRetryAfter(header); got < tt.min || got > tt.max {
+			t.Errorf("%s: cdnRetryAfter() = %v, want between %v and %v", tt.name, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestRefreshAliyunCDNRetry(t *testing.T) {
+	// 先返回503和限流，再成功；每次请求都应重新签名
+	responses := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusServiceUnavailable, `{"Code":"ServiceUnavailable","Message":"busy"}`},
+		{http.StatusBadRequest, `{"Code":"Throttling.User","Message":"too many requests"}`},
+		{http.StatusOK, `{"RefreshTaskId":"task-1","RequestId":"req-1"}`},
+		{http.StatusBadRequest, `{"Code":"InvalidObjectPath.Malformed","Message":"bad path"}`},
+	}
+	var nonces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		nonces = append(nonces, r.PostForm.Get("SignatureNonce"))
+		response := responses[len(nonces)-1]
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(response.status)
+		fmt.Fprint(w, response.body)
+	}))
+	defer server.Close()
+	t.Setenv("ALIYUN_CDN_ENDPOINT", server.URL)
+
+	config := &UltraConfig{
+		AccessKeyID:     "id",
+		AccessKeySecret: "secret",
+		Network:         NetworkConfig{Retries: 2},
+		Interrupt:       &uploadInterrupt{stop: make(chan struct{})},
+	}
+	result, err := refreshAliyunCDN(config, []string{"https://cdn.example.com/a.js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Failed) != 0 || !reflect.DeepEqual(result.TaskIDs, []string{"task-1"}) || result.URLs != 1 {
+		t.Errorf("refreshAliyunCDN() = %+v, want task-1 after retries", result)
+	}
+	if len(nonces) != 3 || nonces[0] == nonces[1] || nonces[1] == nonces[2] {
+		t.Errorf("nonces = %q, want 3 requests signed with different nonces", nonces)
+	}
+
+	// 参数错误不重试
+	result, err = refreshAliyunCDN(config, []string{"https://cdn.example.com/b.js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nonces) != 4 || len(result.Failed) != 1 || !strings.Contains(result.Failed[0].Error(), "InvalidObjectPath.Malformed") {
+		t.Errorf("refreshAliyunCDN() = %+v after %d requests, want one failed batch without retry", result, len(nonces))
+	}
+}
+
+func TestLoadAkamaiCredentials(t *testing.T) {
+	for _, key := range []string{"AKAMAI_CLIENT_TOKEN", "AKAMAI_CLIENT_SECRET", "AKAMAI_ACCESS_TOKEN",
+		"AKAMAI_BASE_URL", "AKAMAI_NETWORK", "DEFAULT_REFRESH_TYPE"} {
+		t.Setenv(key, "")
+	}
+	const base = "AKAMAI_CLIENT_TOKEN=ct\nAKAMAI_CLIENT_SECRET='cs'\nAKAMAI_ACCESS_TOKEN=\"at\"\nAKAMAI_BASE_URL=akab.example.net\n"
+
+	tests := []struct {
+		name        string
+		conf        string
+		env         map[string]string
+		wantNetwork string
+		wantType    string
+		wantErr     bool
+	}{
+		{name: "defaults", conf: base, wantNetwork: "production", wantType: "delete"},
+		{name: "from conf", conf: base + "# 测试网络\nAKAMAI_NETWORK=staging\nDEFAULT_REFRESH_TYPE=remove\n", wantNetwork: "staging", wantType: "remove"},
+		{name: "env overrides conf", conf: base + "AKAMAI_NETWORK=staging\n", env: map[string]string{"AKAMAI_NETWORK": "production"}, wantNetwork: "production", wantType: "delete"},
+		{name: "invalid network", conf: base + "AKAMAI_NETWORK=prod\n", wantErr: true},
+		{name: "invalid type", conf: base + "DEFAULT_REFRESH_TYPE=purge\n", wantErr: true},
+		{name: "missing token", conf: "AKAMAI_BASE_URL=akab.example.net\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		for key, value := range tt.env {
+			os.Setenv(key, value)
+		}
+		path := filepath.Join(t.TempDir(), "akamai.conf")
+		if err := os.WriteFile(path, []byte(tt.conf), 0644); err != nil {
+			t.Fatal(err)
+		}
+		creds, err := loadAkamaiCredentials(path)
+		for key := range tt.env {
+			os.Setenv(key, "")
+		}
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if creds.Network != tt.wantNetwork || creds.RefreshType != tt.wantType {
+			t.Errorf("%s: got network %q type %q, want %q %q", tt.name, creds.Network, creds.RefreshType, tt.wantNetwork, tt.wantType)
+		}
+		if creds.ClientSecret != "cs" || creds.AccessToken != "at" || creds.BaseURL != "https://akab.example.net" {
+			t.Errorf("%s: got %+v", tt.name, creds)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// 服务端去重: bucket中已有相同内容的对象时，用服务端复制代替上传
+type dedupConfig struct {
+	Prefix    string // 上一个版本的前缀，按相同的相对路径查找
+	IndexFile string // 内容哈希索引，记录每个内容对应的对象
+
+	mutex      sync.Mutex
+	index      map[string]dedupIndexEntry // sha256 -> 对象
+	dirty      bool
+	Copied     int   // 服务端复制的文件数
+	BytesSaved int64 // 省去上传的字节数
+}
+
+type dedupIndexEntry struct {
+	CRC64 uint64
+	Size  int64
+	Key   string
+}
+
+// 本地文件的内容摘要，CRC64与OSS的x-oss-hash-crc64ecma一致
+type contentDigest struct {
+	Size   int64
+	CRC64  uint64
+	SHA256 string
+}
+
+var crc64ECMATable = crc64.MakeTable(crc64.ECMA)
+
+func fileDigest(localFile string) (contentDigest, error) {
+	file, err := os.Open(localFile)
+	if err != nil {
+		return contentDigest{}, err
+	}
+	defer file.Close()
+
+	crcHash := crc64.New(crc64ECMATable)
+	shaHash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(crcHash, shaHash), file)
+	if err != nil {
+		return contentDigest{}, err
+	}
+	return contentDigest{Size: size, CRC64: crcHash.Sum64(), SHA256: hex.EncodeToString(shaHash.Sum(nil))}, nil
+}
+
+// 读取索引，每行: sha256 crc64 大小 对象名
+func loadDedupIndex(config *dedupConfig) error {
+	config.index = map[string]dedupIndexEntry{}
+	if config.IndexFile == "" {
+		return nil
+	}
+
+	file, err := os.Open(config.IndexFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取去重索引失败: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 4)
+		if len(fields) != 4 {
+			return fmt.Errorf("去重索引第%d行格式错误", lineNo)
+		}
+		crc, err1 := strconv.ParseUint(fields[1], 10, 64)
+		size, err2 := strconv.ParseInt(fields[2], 10, 64)
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("去重索引第%d行格式错误", lineNo)
+		}
+		config.index[fields[0]] = dedupIndexEntry{CRC64: crc, Size: size, Key: fields[3]}
+	}
+	return scanner.Err()
+}
+
+// 记录已上传的内容，供之后的版本去重
+func (d *dedupConfig) record(digest contentDigest, key string) {
+	if d == nil || d.IndexFile == "" {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.index[digest.SHA256] = dedupIndexEntry{CRC64: digest.CRC64, Size: digest.Size, Key: key}
+	d.dirty = true
+}
+
+func (d *dedupConfig) saveIndex() error {
+	if d == nil || d.IndexFile == "" {
+		return nil
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if !d.dirty {
+		return nil
+	}
+
+	hashes := make([]string, 0, len(d.index))
+	for hash := range d.index {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	tmpFile := d.IndexFile + ".tmp"
+	file, err := os.Create(tmpFile)
+	if err != nil {
+		return fmt.Errorf("写入去重索引失败: %v", err)
+	}
+	writer := bufio.NewWriter(file)
+	fmt.Fprintf(writer, "# sha256 crc64 size key\n")
+	for _, hash := range hashes {
+		entry := d.index[hash]
+		fmt.Fprintf(writer, "%s %d %d %s\n", hash, entry.CRC64, entry.Size, entry.Key)
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("写入去重索引失败: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入去重索引失败: %v", err)
+	}
+	if err := os.Rename(tmpFile, d.IndexFile); err != nil {
+		return fmt.Errorf("写入去重索引失败: %v", err)
+	}
+	d.dirty = false
+	return nil
+}
+
+// 候选的已有对象: 上一版本前缀下的同名文件，以及索引中相同内容的对象
+func (d *dedupConfig) candidates(config *UltraConfig, digest contentDigest, remoteObject string) []string {
+	var keys []string
+	if d.Prefix != "" {
+		relPath := strings.TrimPrefix(remoteObject, config.RemoteObject)
+		if relPath == remoteObject || relPath == "" {
+			relPath = path.Base(remoteObject)
+		}
+		keys = append(keys, path.Join(d.Prefix, strings.TrimPrefix(relPath, "/")))
+	}
+
+	d.mutex.Lock()
+	entry, ok := d.index[digest.SHA256]
+	d.mutex.Unlock()
+	if ok && entry.Size == digest.Size && entry.CRC64 == digest.CRC64 {
+		keys = append(keys, entry.Key)
+	}
+	return keys
+}
+
+// 内容已存在时执行服务端复制，返回是否已复制及新对象的ETag
+// 找不到或复制失败时返回false，由调用方正常上传
+func dedupCopy(config *UltraConfig, bucket *oss.Bucket, digest contentDigest, remoteObject string, options []oss.Option) (bool, string) {
+	d := config.Dedup
+	for _, key := range d.candidates(config, digest, remoteObject) {
+		if key == remoteObject {
+			continue
+		}
+
+		var header http.Header
+		err := withRetry(config, "检查 "+key, func() error {
+			var err error
+			header, err = bucket.GetObjectDetailedMeta(key)
+			return err
+		})
+		if err != nil {
+			continue
+		}
+		size, _ := strconv.ParseInt(header.Get(oss.HTTPHeaderContentLength), 10, 64)
+		crc, err := strconv.ParseUint(header.Get(oss.HTTPHeaderOssCRC64), 10, 64)
+		if err != nil || size != digest.Size || crc != digest.CRC64 {
+			continue
+		}
+
+		// 指定了请求头时替换元数据，否则沿用源对象的元数据
+		if len(options) > 0 {
+			options = append(options, oss.MetadataDirective(oss.MetaReplace))
+		}
+		var etag string
+		err = withRetry(config, "服务端复制 "+remoteObject, func() error {
+			var err error
+			etag, err = serverSideCopy(config.BucketName, bucket, copyTask{SrcKey: key, DstKey: remoteObject, Size: size}, options...)
+			return err
+		})
+		if err != nil {
+			fmt.Printf("⚠️  服务端复制失败，改为上传: %v\n", err)
+			return false, ""
+		}
+
+		d.mutex.Lock()
+		d.Copied++
+		d.BytesSaved += digest.Size
+		d.mutex.Unlock()
+		config.Metrics.observeDedup(digest.Size)
+		d.record(digest, remoteObject)
+		fmt.Printf("♻️  内容与 %s 相同，已服务端复制 (省去上传 %s)\n", key, humanSize(digest.Size))
+		return true, etag
+	}
+	return false, ""
+}
+
+// 规范化去重前缀
+func normalizeDedupPrefix(prefix string) string {
+	return strings.TrimSuffix(normalizeRemotePath(prefix), "/")
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"hash/crc64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileDigest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fileDigest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := contentDigest{
+		Size:   5,
+		CRC64:  crc64.Checksum([]byte("hello"), crc64.MakeTable(crc64.ECMA)),
+		SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	}
+	if got != want {
+		t.Errorf("fileDigest() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadDedupIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]dedupIndexEntry
+		wantErr bool
+	}{
+		{
+			name:    "entries",
+			content: "# sha256 crc64 size key\naaa 123 10 releases/v1/a.js\n\nbbb 456 0 releases/v1/my file.txt\n",
+			want: map[string]dedupIndexEntry{
+				"aaa": {CRC64: 123, Size: 10, Key: "releases/v1/a.js"},
+				"bbb": {CRC64: 456, Size: 0, Key: "releases/v1/my file.txt"},
+			},
+		},
+		{name: "later entry wins", content: "aaa 1 1 old.js\naaa 2 2 new.js\n", want: map[string]dedupIndexEntry{"aaa": {CRC64: 2, Size: 2, Key: "new.js"}}},
+		{name: "missing key", content: "aaa 123 10\n", wantErr: true},
+		{name: "bad crc", content: "aaa x 10 a.js\n", wantErr: true},
+		{name: "bad size", content: "aaa 123 -x a.js\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "dedup.idx")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config := &dedupConfig{IndexFile: path}
+		err := loadDedupIndex(config)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: loadDedupIndex() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(config.index, tt.want) {
+			t.Errorf("%s: loadDedupIndex() = %+v, want %+v", tt.name, config.index, tt.want)
+		}
+	}
+
+	// 索引文件不存在时从空索引开始
+	config := &dedupConfig{IndexFile: filepath.Join(t.TempDir(), "missing.idx")}
+	if err := loadDedupIndex(config); err != nil || len(config.index) != 0 {
+		t.Errorf("loadDedupIndex() of a missing file = %v, %v", config.index, err)
+	}
+}
+
+func TestDedupIndexRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.idx")
+	config := &dedupConfig{IndexFile: path}
+	if err := loadDedupIndex(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有新记录时不写文件
+	if err := config.saveIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("saveIndex() without changes created the index")
+	}
+
+	config.record(contentDigest{Size: 3, CRC64: 42, SHA256: "bbb"}, "v1/b c.js")
+	config.record(contentDigest{Size: 1, CRC64: 7, SHA256: "aaa"}, "v1/a.js")
+	if err := config.saveIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &dedupConfig{IndexFile: path}
+	if err := loadDedupIndex(loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.index, config.index) {
+		t.Errorf("reloaded index = %+v, want %+v", loaded.index, config.index)
+	}
+}
+
+func TestDedupCandidates(t *testing.T) {
+	digest := contentDigest{Size: 10, CRC64: 123, SHA256: "aaa"}
+	index := map[string]dedupIndexEntry{"aaa": {CRC64: 123, Size: 10, Key: "old/a.js"}}
+	tests := []struct {
+		name         string
+		prefix       string
+		index        map[string]dedupIndexEntry
+		remoteRoot   string
+		remoteObject string
+		want         []string
+	}{
+		{"same relative path", "releases/v1", nil, "releases/v2/", "releases/v2/js/a.js", []string{"releases/v1/js/a.js"}},
+		{"single file", "releases/v1", nil, "releases/v2/app.apk", "releases/v2/app.apk", []string{"releases/v1/app.apk"}},
+		{"outside remote root", "releases/v1", nil, "releases/v2/", "other/a.js", []string{"releases/v1/a.js"}},
+		{"index match", "", index, "releases/v2/", "releases/v2/a.js", []string{"old/a.js"}},
+		{"prefix and index", "releases/v1", index, "releases/v2/", "releases/v2/a.js", []string{"releases/v1/a.js", "old/a.js"}},
+		{"index size differs", "", map[string]dedupIndexEntry{"aaa": {CRC64: 123, Size: 11, Key: "old/a.js"}}, "", "a.js", nil},
+		{"index crc differs", "", map[string]dedupIndexEntry{"aaa": {CRC64: 124, Size: 10, Key: "old/a.js"}}, "", "a.js", nil},
+	}
+	for _, tt := range tests {
+		d := &dedupConfig{Prefix: tt.prefix, index: tt.index}
+		config := &UltraConfig{RemoteObject: tt.remoteRoot}
+		if got := d.candidates(config, digest, tt.remoteObject); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: candidates() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeDedupPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"releases/v1", "releases/v1"},
+		{"/releases/v1/", "releases/v1"},
+		{`releases\v1\`, "releases/v1"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeDedupPrefix(tt.prefix); got != tt.want {
+			t.Errorf("normalizeDedupPrefix(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,1343 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash/crc64"
+	"io"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EmulatorConfig 本地OSS模拟服务配置
+type EmulatorConfig struct {
+	Addr      string        // 监听地址
+	Root      string        // 数据目录
+	Latency   time.Duration // 每个请求附加的延迟
+	ErrorRate float64       // 随机返回5xx的概率
+	DropRate  float64       // 随机断开连接的概率
+	Quiet     bool          // 不打印请求日志
+}
+
+const (
+	emulatorMetaDir    = ".oss_meta"       // 对象元数据目录
+	emulatorUploadDir  = ".oss_uploads"    // 分片上传临时目录
+	emulatorDirMarker  = ".oss_dir_marker" // 以/结尾的目录对象
+	emulatorMaxKeys    = 1000
+	emulatorDefaultKey = 100
+)
+
+var emulatorCRCTable = crc64.MakeTable(crc64.ECMA)
+
+// 对象元数据，保存在 <root>/.oss_meta/<bucket>/<key>.json
+type emulatorObjectMeta struct {
+	ETag         string            `json:"etag"`
+	Size         int64             `json:"size"`
+	CRC64        uint64            `json:"crc64"`
+	ContentType  string            `json:"contentType"`
+	LastModified time.Time         `json:"lastModified"`
+	Multipart    bool              `json:"multipart"`
+	Headers      map[string]string `json:"headers,omitempty"`
+}
+
+// 分片上传任务信息，保存在 <root>/.oss_uploads/<uploadId>/upload.json
+type emulatorUpload struct {
+	Bucket      string            `json:"bucket"`
+	Key         string            `json:"key"`
+	Initiated   time.Time         `json:"initiated"`
+	ContentType string            `json:"contentType"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+type ossEmulator struct {
+	config *EmulatorConfig
+	mu     sync.Mutex
+	rnd    *mathrand.Rand
+	rndMu  sync.Mutex
+}
+
+type emulatorError struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	RequestID string   `xml:"RequestId"`
+	HostID    string   `xml:"HostId"`
+	Key       string   `xml:"Key,omitempty"`
+}
+
+type emulatorListEntry struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Type         string `xml:"Type"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type emulatorCommonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type emulatorListResult struct {
+	XMLName               xml.Name               `xml:"ListBucketResult"`
+	Name                  string                 `xml:"Name"`
+	Prefix                string                 `xml:"Prefix"`
+	Marker                string                 `xml:"Marker,omitempty"`
+	StartAfter            string                 `xml:"StartAfter,omitempty"`
+	ContinuationToken     string                 `xml:"ContinuationToken,omitempty"`
+	MaxKeys               int                    `xml:"MaxKeys"`
+	Delimiter             string                 `xml:"Delimiter"`
+	EncodingType          string                 `xml:"EncodingType,omitempty"`
+	IsTruncated           bool                   `xml:"IsTruncated"`
+	NextMarker            string                 `xml:"NextMarker,omitempty"`
+	NextContinuationToken string                 `xml:"NextContinuationToken,omitempty"`
+	KeyCount              int                    `xml:"KeyCount,omitempty"`
+	Contents              []emulatorListEntry    `xml:"Contents"`
+	CommonPrefixes        []emulatorCommonPrefix `xml:"CommonPrefixes"`
+}
+
+type emulatorDeleteRequest struct {
+	XMLName xml.Name `xml:"Delete"`
+	Quiet   bool     `xml:"Quiet"`
+	Objects []struct {
+		Key string `xml:"Key"`
+	} `xml:"Object"`
+}
+
+type emulatorDeleted struct {
+	Key string `xml:"Key"`
+}
+
+type emulatorDeleteResult struct {
+	XMLName xml.Name          `xml:"DeleteResult"`
+	Deleted []emulatorDeleted `xml:"Deleted"`
+}
+
+type emulatorInitiateResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+type emulatorCompleteRequest struct {
+	XMLName xml.Name `xml:"CompleteMultipartUpload"`
+	Parts   []struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+type emulatorUploadEntry struct {
+	Key       string `xml:"Key"`
+	UploadID  string `xml:"UploadId"`
+	Initiated string `xml:"Initiated"`
+}
+
+type emulatorListUploadsResult struct {
+	XMLName            xml.Name              `xml:"ListMultipartUploadsResult"`
+	Bucket             string                `xml:"Bucket"`
+	Prefix             string                `xml:"Prefix"`
+	KeyMarker          string                `xml:"KeyMarker"`
+	UploadIDMarker     string                `xml:"UploadIdMarker"`
+	NextKeyMarker      string                `xml:"NextKeyMarker"`
+	NextUploadIDMarker string                `xml:"NextUploadIdMarker"`
+	MaxUploads         int                   `xml:"MaxUploads"`
+	IsTruncated        bool                  `xml:"IsTruncated"`
+	Uploads            []emulatorUploadEntry `xml:"Upload"`
+}
+
+type emulatorPartEntry struct {
+	PartNumber   int    `xml:"PartNumber"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+}
+
+type emulatorListPartsResult struct {
+	XMLName              xml.Name            `xml:"ListPartsResult"`
+	Bucket               string              `xml:"Bucket"`
+	Key                  string              `xml:"Key"`
+	UploadID             string              `xml:"UploadId"`
+	NextPartNumberMarker int                 `xml:"NextPartNumberMarker"`
+	MaxParts             int                 `xml:"MaxParts"`
+	IsTruncated          bool                `xml:"IsTruncated"`
+	Parts                []emulatorPartEntry `xml:"Part"`
+}
+
+type emulatorCopyResult struct {
+	XMLName      xml.Name
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+}
+
+type emulatorCompleteResult struct {
+	XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+func showServeUsage() {
+	fmt.Printf(`OSS本地模拟服务 - 离线开发和测试
+
+用法: %s serve [选项]
+
+选项:
+  --addr ADDR         监听地址，默认127.0.0.1:9000
+  --root DIR          数据目录，默认./oss_data
+  --latency DURATION  每个请求附加延迟，如200ms
+  --error-rate RATE   随机返回5xx的概率(0-1)
+  --drop-rate RATE    随机断开连接的概率(0-1)
+  -q                  不打印请求日志
+  -h                  帮助
+
+支持的接口:
+  PutObject, GetObject(Range), HeadObject, DeleteObject, DeleteObjects,
+  CopyObject, ListObjects(V1/V2), InitiateMultipartUpload, UploadPart,
+  UploadPartCopy, CompleteMultipartUpload, AbortMultipartUpload,
+  ListMultipartUploads, ListParts
+
+使用方法:
+  %s serve --root /tmp/oss --error-rate 0.05
+  OSS_ENDPOINT=http://127.0.0.1:9000 OSS_ACCESS_KEY_ID=test OSS_ACCESS_KEY_SECRET=test \
+    %s ./build/ releases/v1.0/ -d
+
+注意: 模拟服务不校验签名，bucket在首次写入时自动创建
+`, os.Args[0], os.Args[0], os.Args[0])
+}
+
+func parseServeConfig(args []string) (*EmulatorConfig, error) {
+	config := &EmulatorConfig{
+		Addr: "127.0.0.1:9000",
+		Root: "./oss_data",
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--addr", "--root", "--latency", "--error-rate", "--drop-rate":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s 需要参数", args[i])
+			}
+			value := args[i+1]
+			i++
+			switch args[i-1] {
+			case "--addr":
+				config.Addr = value
+			case "--root":
+				config.Root = value
+			case "--latency":
+				latency, err := time.ParseDuration(value)
+				if err != nil {
+					return nil, fmt.Errorf("无效的延迟: %s", value)
+				}
+				config.Latency = latency
+			case "--error-rate", "--drop-rate":
+				rate, err := strconv.ParseFloat(value, 64)
+				if err != nil || rate < 0 || rate > 1 {
+					return nil, fmt.Errorf("概率必须在0-1之间: %s", value)
+				}
+				if args[i-1] == "--error-rate" {
+					config.ErrorRate = rate
+				} else {
+					config.DropRate = rate
+				}
+			}
+		case "-q":
+			config.Quiet = true
+		case "-h", "--help":
+			showServeUsage()
+			os.Exit(0)
+		default:
+			return nil, fmt.Errorf("未知参数: %s", args[i])
+		}
+	}
+
+	return config, nil
+}
+
+func runServe(args []string) error {
+	config, err := parseServeConfig(args)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(config.Root, 0755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %v", err)
+	}
+
+	emulator := &ossEmulator{
+		config: config,
+		rnd:    mathrand.New(mathrand.NewSource(time.Now().UnixNano())),
+	}
+
+	fmt.Printf("🧪 OSS本地模拟服务启动\n")
+	fmt.Printf("监听: http://%s\n", config.Addr)
+	fmt.Printf("数据目录: %s\n", config.Root)
+	if config.Latency > 0 || config.ErrorRate > 0 || config.DropRate > 0 {
+		fmt.Printf("💥 故障注入: 延迟%v, 5xx概率%.2f, 断连概率%.2f\n",
+			config.Latency, config.ErrorRate, config.DropRate)
+	}
+	fmt.Printf("使用: export OSS_ENDPOINT=http://%s\n", config.Addr)
+
+	return http.ListenAndServe(config.Addr, emulator)
+}
+
+func (e *ossEmulator) chance(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	e.rndMu.Lock()
+	defer e.rndMu.Unlock()
+	return e.rnd.Float64() < rate
+}
+
+func (e *ossEmulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := newEmulatorID(12)
+	w.Header().Set("x-oss-request-id", requestID)
+	w.Header().Set("Server", "AliyunOSS")
+	recorder := &emulatorStatusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	defer func() {
+		if !e.config.Quiet {
+			fmt.Printf("%s %s %s?%s -> %d\n", time.Now().Format("15:04:05"),
+				r.Method, r.URL.Path, r.URL.RawQuery, recorder.status)
+		}
+	}()
+
+	// 故障注入
+	if e.config.Latency > 0 {
+		time.Sleep(e.config.Latency)
+	}
+	if e.chance(e.config.DropRate) {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				recorder.status = 0
+				return
+			}
+		}
+	}
+	if e.chance(e.config.ErrorRate) {
+		io.Copy(io.Discard, r.Body)
+		if e.chance(0.5) {
+			e.writeError(recorder, r, http.StatusInternalServerError, "InternalError", "injected fault", "")
+		} else {
+			e.writeError(recorder, r, http.StatusServiceUnavailable, "ServiceUnavailable", "injected fault", "")
+		}
+		return
+	}
+
+	bucket, key := e.splitRequestPath(r)
+	if bucket == "" {
+		e.writeError(recorder, r, http.StatusNotImplemented, "NotImplemented", "service level operations are not supported", "")
+		return
+	}
+	if !isValidEmulatorBucket(bucket) {
+		e.writeError(recorder, r, http.StatusBadRequest, "InvalidBucketName", "invalid bucket name: "+bucket, "")
+		return
+	}
+	if key != "" && !isValidEmulatorKey(key) {
+		e.writeError(recorder, r, http.StatusBadRequest, "InvalidObjectName", "invalid object name", key)
+		return
+	}
+
+	query := r.URL.Query()
+	// 预签名URL过期检查，签名本身不校验
+	if expires := query.Get("Expires"); expires != "" {
+		if seconds, err := strconv.ParseInt(expires, 10, 64); err == nil && time.Now().Unix() > seconds {
+			e.writeError(recorder, r, http.StatusForbidden, "AccessDenied", "Request has expired.", key)
+			return
+		}
+	}
+
+	if key == "" {
+		switch {
+		case r.Method == http.MethodGet:
+			e.listObjects(recorder, r, bucket)
+		case r.Method == http.MethodPost && query.Has("delete"):
+			e.deleteObjects(recorder, r, bucket)
+		case r.Method == http.MethodPut:
+			if err := os.MkdirAll(filepath.Join(e.config.Root, bucket), 0755); err != nil {
+				e.writeError(recorder, r, http.StatusInternalServerError, "InternalError", err.Error(), "")
+				return
+			}
+			recorder.WriteHeader(http.StatusOK)
+		default:
+			e.writeError(recorder, r, http.StatusNotImplemented, "NotImplemented", "unsupported bucket operation", "")
+		}
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPut && query.Has("uploadId") && r.Header.Get("x-oss-copy-source") != "":
+		e.uploadPartCopy(recorder, r, bucket, key)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		e.uploadPart(recorder, r, bucket, key)
+	case r.Method == http.MethodPut && r.Header.Get("x-oss-copy-source") != "":
+		e.copyObject(recorder, r, bucket, key)
+	case r.Method == http.MethodPut:
+		e.putObject(recorder, r, bucket, key)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		e.initiateMultipart(recorder, r, bucket, key)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		e.completeMultipart(recorder, r, bucket, key)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		e.abortMultipart(recorder, r, bucket, key)
+	case r.Method == http.MethodDelete:
+		e.deleteObject(recorder, r, bucket, key)
+	case r.Method == http.MethodGet && query.Has("uploadId"):
+		e.listParts(recorder, r, bucket, key)
+	case r.Method == http.MethodHead:
+		e.headObject(recorder, r, bucket, key)
+	case r.Method == http.MethodGet:
+		e.getObject(recorder, r, bucket, key)
+	default:
+		e.writeError(recorder, r, http.StatusNotImplemented, "NotImplemented", "unsupported object operation", key)
+	}
+}
+
+// 同时支持path-style (/bucket/key) 和 virtual-host (bucket.host/key) 两种访问方式
+func (e *ossEmulator) splitRequestPath(r *http.Request) (string, string) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if net.ParseIP(host) == nil && strings.Contains(host, ".") {
+		return host[:strings.Index(host, ".")], path
+	}
+
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func isValidEmulatorBucket(bucket string) bool {
+	if len(bucket) < 3 || len(bucket) > 63 {
+		return false
+	}
+	for _, c := range bucket {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return bucket[0] != '-' && bucket[len(bucket)-1] != '-'
+}
+
+func isValidEmulatorKey(key string) bool {
+	if len(key) > 1023 || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." || segment == "." || segment == emulatorDirMarker {
+			return false
+		}
+	}
+	return true
+}
+
+// 对象数据文件路径，以/结尾的key保存为目录下的标记文件
+func (e *ossEmulator) objectPath(bucket, key string) string {
+	if strings.HasSuffix(key, "/") {
+		return filepath.Join(e.config.Root, bucket, filepath.FromSlash(key), emulatorDirMarker)
+	}
+	return filepath.Join(e.config.Root, bucket, filepath.FromSlash(key))
+}
+
+func (e *ossEmulator) metaPath(bucket, key string) string {
+	rel, _ := filepath.Rel(e.config.Root, e.objectPath(bucket, key))
+	return filepath.Join(e.config.Root, emulatorMetaDir, rel+".json")
+}
+
+func (e *ossEmulator) uploadPath(uploadID string) string {
+	return filepath.Join(e.config.Root, emulatorUploadDir, filepath.Base(uploadID))
+}
+
+// 读取对象元数据，手工放入数据目录的文件没有元数据时现场计算
+func (e *ossEmulator) loadMeta(bucket, key string) (*emulatorObjectMeta, error) {
+	stat, err := os.Stat(e.objectPath(bucket, key))
+	if err != nil || stat.IsDir() {
+		return nil, os.ErrNotExist
+	}
+
+	meta := &emulatorObjectMeta{}
+	if content, err := os.ReadFile(e.metaPath(bucket, key)); err == nil {
+		if err := json.Unmarshal(content, meta); err == nil && meta.Size == stat.Size() {
+			return meta, nil
+		}
+	}
+
+	file, err := os.Open(e.objectPath(bucket, key))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	md5Hash := md5.New()
+	crcHash := crc64.New(emulatorCRCTable)
+	size, err := io.Copy(io.MultiWriter(md5Hash, crcHash), file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emulatorObjectMeta{
+		ETag:         fmt.Sprintf("\"%X\"", md5Hash.Sum(nil)),
+		Size:         size,
+		CRC64:        crcHash.Sum64(),
+		ContentType:  "application/octet-stream",
+		LastModified: stat.ModTime().UTC(),
+	}, nil
+}
+
+func (e *ossEmulator) saveMeta(bucket, key string, meta *emulatorObjectMeta) error {
+	path := e.metaPath(bucket, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// 将body写入临时文件，返回md5和crc64
+func writeEmulatorTempFile(dir string, body io.Reader) (string, []byte, uint64, int64, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", nil, 0, 0, err
+	}
+	tmp, err := os.CreateTemp(dir, ".oss_tmp_*")
+	if err != nil {
+		return "", nil, 0, 0, err
+	}
+	defer tmp.Close()
+
+	md5Hash := md5.New()
+	crcHash := crc64.New(emulatorCRCTable)
+	size, err := io.Copy(io.MultiWriter(tmp, md5Hash, crcHash), body)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, 0, 0, err
+	}
+	return tmp.Name(), md5Hash.Sum(nil), crcHash.Sum64(), size, nil
+}
+
+// 从请求头中提取需要保存的对象头信息
+func emulatorObjectHeaders(r *http.Request) map[string]string {
+	headers := map[string]string{}
+	for name, values := range r.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-oss-meta-") || lower == "cache-control" ||
+			lower == "content-disposition" || lower == "content-encoding" ||
+			lower == "content-language" || lower == "expires" {
+			headers[name] = values[0]
+		}
+	}
+	return headers
+}
+
+func (e *ossEmulator) putObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	dataPath := e.objectPath(bucket, key)
+	tmpPath, sum, crc, size, err := writeEmulatorTempFile(filepath.Dir(dataPath), r.Body)
+	if err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+
+	if expected := r.Header.Get("Content-MD5"); expected != "" {
+		if expected != base64.StdEncoding.EncodeToString(sum) {
+			os.Remove(tmpPath)
+			e.writeError(w, r, http.StatusBadRequest, "InvalidDigest", "Content-MD5 mismatch", key)
+			return
+		}
+	}
+
+	meta := &emulatorObjectMeta{
+		ETag:         fmt.Sprintf("\"%X\"", sum),
+		Size:         size,
+		CRC64:        crc,
+		ContentType:  emulatorContentType(r),
+		LastModified: time.Now().UTC(),
+		Headers:      emulatorObjectHeaders(r),
+	}
+
+	e.mu.Lock()
+	err = os.Rename(tmpPath, dataPath)
+	if err == nil {
+		err = e.saveMeta(bucket, key, meta)
+	}
+	e.mu.Unlock()
+	if err != nil {
+		os.Remove(tmpPath)
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+
+	w.Header().Set("ETag", meta.ETag)
+	w.Header().Set("x-oss-hash-crc64ecma", strconv.FormatUint(crc, 10))
+	w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(sum))
+	w.WriteHeader(http.StatusOK)
+}
+
+func emulatorContentType(r *http.Request) string {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+func (e *ossEmulator) setObjectHeaders(w http.ResponseWriter, meta *emulatorObjectMeta) {
+	w.Header().Set("ETag", meta.ETag)
+	w.Header().Set("Content-Type", meta.ContentType)
+	w.Header().Set("Last-Modified", meta.LastModified.Format(http.TimeFormat))
+	w.Header().Set("x-oss-hash-crc64ecma", strconv.FormatUint(meta.CRC64, 10))
+	w.Header().Set("x-oss-storage-class", "Standard")
+	if meta.Multipart {
+		w.Header().Set("x-oss-object-type", "Multipart")
+	} else {
+		w.Header().Set("x-oss-object-type", "Normal")
+	}
+	for name, value := range meta.Headers {
+		w.Header().Set(name, value)
+	}
+}
+
+func (e *ossEmulator) headObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	meta, err := e.loadMeta(bucket, key)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	e.setObjectHeaders(w, meta)
+	w.Header().Set("Content-Length", strconv.FormatInt(meta.Size, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (e *ossEmulator) getObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	meta, err := e.loadMeta(bucket, key)
+	if err != nil {
+		e.writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.", key)
+		return
+	}
+
+	file, err := os.Open(e.objectPath(bucket, key))
+	if err != nil {
+		e.writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.", key)
+		return
+	}
+	defer file.Close()
+
+	e.setObjectHeaders(w, meta)
+	setEmulatorResponseOverrides(w, r)
+	// 带Range的请求返回的是部分内容，CRC只对完整对象有效
+	if r.Header.Get("Range") != "" {
+		w.Header().Del("x-oss-hash-crc64ecma")
+	}
+	http.ServeContent(w, r, "", meta.LastModified, file)
+}
+
+// GET请求可通过response-*参数覆盖响应头
+func setEmulatorResponseOverrides(w http.ResponseWriter, r *http.Request) {
+	overrides := map[string]string{
+		"response-content-type":        "Content-Type",
+		"response-content-language":    "Content-Language",
+		"response-expires":             "Expires",
+		"response-cache-control":       "Cache-Control",
+		"response-content-disposition": "Content-Disposition",
+		"response-content-encoding":    "Content-Encoding",
+	}
+	query := r.URL.Query()
+	for param, header := range overrides {
+		if value := query.Get(param); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+}
+
+func (e *ossEmulator) deleteObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	e.removeObject(bucket, key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *ossEmulator) removeObject(bucket, key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	os.Remove(e.objectPath(bucket, key))
+	os.Remove(e.metaPath(bucket, key))
+}
+
+func (e *ossEmulator) deleteObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	var request emulatorDeleteRequest
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		e.writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error(), "")
+		return
+	}
+	if len(request.Objects) > 1000 {
+		e.writeError(w, r, http.StatusBadRequest, "MalformedXML", "too many objects, max 1000", "")
+		return
+	}
+
+	encodeKeys := r.URL.Query().Get("encoding-type") == "url"
+	result := emulatorDeleteResult{}
+	for _, object := range request.Objects {
+		if !isValidEmulatorKey(object.Key) {
+			continue
+		}
+		e.removeObject(bucket, object.Key)
+		key := object.Key
+		if encodeKeys {
+			key = url.QueryEscape(key)
+		}
+		result.Deleted = append(result.Deleted, emulatorDeleted{Key: key})
+	}
+
+	if request.Quiet {
+		result.Deleted = nil
+	}
+	e.writeXML(w, http.StatusOK, result)
+}
+
+// 列出bucket下所有对象key，按字典序排序
+func (e *ossEmulator) allKeys(bucket string) ([]string, error) {
+	root := filepath.Join(e.config.Root, bucket)
+	var keys []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".oss_tmp_") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if info.Name() == emulatorDirMarker {
+			key = strings.TrimSuffix(key, emulatorDirMarker)
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	sort.Strings(keys)
+	return keys, err
+}
+
+func (e *ossEmulator) listObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	query := r.URL.Query()
+	if query.Has("uploads") {
+		e.listMultipartUploads(w, r, bucket)
+		return
+	}
+
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	encodeKeys := query.Get("encoding-type") == "url"
+	isV2 := query.Get("list-type") == "2"
+
+	maxKeys := emulatorDefaultKey
+	if value := query.Get("max-keys"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > emulatorMaxKeys {
+			e.writeError(w, r, http.StatusBadRequest, "InvalidArgument", "invalid max-keys", "")
+			return
+		}
+		maxKeys = parsed
+	}
+
+	marker := query.Get("marker")
+	if isV2 {
+		marker = query.Get("start-after")
+		if token := query.Get("continuation-token"); token != "" {
+			decoded, err := hex.DecodeString(token)
+			if err != nil {
+				e.writeError(w, r, http.StatusBadRequest, "InvalidArgument", "invalid continuation-token", "")
+				return
+			}
+			marker = string(decoded)
+		}
+	}
+
+	keys, err := e.allKeys(bucket)
+	if err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), "")
+		return
+	}
+
+	encode := func(s string) string {
+		if encodeKeys {
+			return url.QueryEscape(s)
+		}
+		return s
+	}
+
+	result := emulatorListResult{
+		Name:      bucket,
+		Prefix:    encode(prefix),
+		MaxKeys:   maxKeys,
+		Delimiter: encode(delimiter),
+	}
+	if encodeKeys {
+		result.EncodingType = "url"
+	}
+	if isV2 {
+		result.StartAfter = encode(query.Get("start-after"))
+		result.ContinuationToken = query.Get("continuation-token")
+	} else {
+		result.Marker = encode(marker)
+	}
+
+	seenPrefixes := map[string]bool{}
+	count := 0
+	last := ""
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key <= marker {
+			continue
+		}
+
+		commonPrefix := ""
+		if delimiter != "" {
+			if idx := strings.Index(key[len(prefix):], delimiter); idx >= 0 {
+				commonPrefix = key[:len(prefix)+idx+len(delimiter)]
+			}
+		}
+		if commonPrefix != "" && (seenPrefixes[commonPrefix] || commonPrefix <= marker) {
+			continue
+		}
+
+		if count >= maxKeys {
+			result.IsTruncated = true
+			break
+		}
+
+		if commonPrefix != "" {
+			seenPrefixes[commonPrefix] = true
+			result.CommonPrefixes = append(result.CommonPrefixes, emulatorCommonPrefix{Prefix: encode(commonPrefix)})
+			last = commonPrefix
+		} else {
+			meta, err := e.loadMeta(bucket, key)
+			if err != nil {
+				continue
+			}
+			objectType := "Normal"
+			if meta.Multipart {
+				objectType = "Multipart"
+			}
+			result.Contents = append(result.Contents, emulatorListEntry{
+				Key:          encode(key),
+				LastModified: meta.LastModified.Format("2006-01-02T15:04:05.000Z"),
+				ETag:         meta.ETag,
+				Type:         objectType,
+				Size:         meta.Size,
+				StorageClass: "Standard",
+			})
+			last = key
+		}
+		count++
+	}
+
+	if result.IsTruncated {
+		if isV2 {
+			result.NextContinuationToken = hex.EncodeToString([]byte(last))
+		} else {
+			result.NextMarker = encode(last)
+		}
+	}
+	if isV2 {
+		result.KeyCount = count
+	}
+	e.writeXML(w, http.StatusOK, result)
+}
+
+func (e *ossEmulator) initiateMultipart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	uploadID := newEmulatorID(16)
+	upload := &emulatorUpload{
+		Bucket:      bucket,
+		Key:         key,
+		Initiated:   time.Now().UTC(),
+		ContentType: emulatorContentType(r),
+		Headers:     emulatorObjectHeaders(r),
+	}
+
+	dir := e.uploadPath(uploadID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+	content, _ := json.MarshalIndent(upload, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, "upload.json"), content, 0644); err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+
+	e.writeXML(w, http.StatusOK, emulatorInitiateResult{Bucket: bucket, Key: key, UploadID: uploadID})
+}
+
+func (e *ossEmulator) loadUpload(uploadID, bucket, key string) (*emulatorUpload, error) {
+	content, err := os.ReadFile(filepath.Join(e.uploadPath(uploadID), "upload.json"))
+	if err != nil {
+		return nil, err
+	}
+	upload := &emulatorUpload{}
+	if err := json.Unmarshal(content, upload); err != nil {
+		return nil, err
+	}
+	if upload.Bucket != bucket || upload.Key != key {
+		return nil, os.ErrNotExist
+	}
+	return upload, nil
+}
+
+func (e *ossEmulator) uploadPart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	partNumber, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > 10000 {
+		e.writeError(w, r, http.StatusBadRequest, "InvalidArgument", "invalid partNumber", key)
+		return
+	}
+	if _, err := e.loadUpload(uploadID, bucket, key); err != nil {
+		e.writeError(w, r, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.", key)
+		return
+	}
+
+	dir := e.uploadPath(uploadID)
+	tmpPath, sum, crc, _, err := writeEmulatorTempFile(dir, r.Body)
+	if err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+	if err := os.Rename(tmpPath, filepath.Join(dir, fmt.Sprintf("%05d.part", partNumber))); err != nil {
+		os.Remove(tmpPath)
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+
+	w.Header().Set("ETag", fmt.Sprintf("\"%X\"", sum))
+	w.Header().Set("x-oss-hash-crc64ecma", strconv.FormatUint(crc, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (e *ossEmulator) completeMultipart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	uploadID := r.URL.Query().Get("uploadId")
+	upload, err := e.loadUpload(uploadID, bucket, key)
+	if err != nil {
+		e.writeError(w, r, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.", key)
+		return
+	}
+
+	var request emulatorCompleteRequest
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		e.writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error(), key)
+		return
+	}
+	if len(request.Parts) == 0 {
+		e.writeError(w, r, http.StatusBadRequest, "InvalidPart", "no parts specified", key)
+		return
+	}
+
+	dir := e.uploadPath(uploadID)
+	dataPath := e.objectPath(bucket, key)
+	if err := os.MkdirAll(filepath.Dir(dataPath), 0755); err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dataPath), ".oss_tmp_*")
+	if err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	crcHash := crc64.New(emulatorCRCTable)
+	partSums := md5.New()
+	var size int64
+	lastPart := 0
+	for _, part := range request.Parts {
+		if part.PartNumber <= lastPart {
+			e.writeError(w, r, http.StatusBadRequest, "InvalidPartOrder", "parts must be in ascending order", key)
+			return
+		}
+		lastPart = part.PartNumber
+
+		content, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("%05d.part", part.PartNumber)))
+		if err != nil {
+			e.writeError(w, r, http.StatusBadRequest, "InvalidPart", fmt.Sprintf("part %d not found", part.PartNumber), key)
+			return
+		}
+		sum := md5.Sum(content)
+		if !strings.EqualFold(strings.Trim(part.ETag, "\""), hex.EncodeToString(sum[:])) {
+			e.writeError(w, r, http.StatusBadRequest, "InvalidPart", fmt.Sprintf("part %d etag mismatch", part.PartNumber), key)
+			return
+		}
+		partSums.Write(sum[:])
+		crcHash.Write(content)
+		if _, err := tmp.Write(content); err != nil {
+			e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+			return
+		}
+		size += int64(len(content))
+	}
+	tmp.Close()
+
+	meta := &emulatorObjectMeta{
+		ETag:         fmt.Sprintf("\"%X-%d\"", partSums.Sum(nil), len(request.Parts)),
+		Size:         size,
+		CRC64:        crcHash.Sum64(),
+		ContentType:  upload.ContentType,
+		LastModified: time.Now().UTC(),
+		Multipart:    true,
+		Headers:      upload.Headers,
+	}
+
+	e.mu.Lock()
+	err = os.Rename(tmp.Name(), dataPath)
+	if err == nil {
+		err = e.saveMeta(bucket, key, meta)
+	}
+	e.mu.Unlock()
+	if err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+	os.RemoveAll(dir)
+
+	w.Header().Set("ETag", meta.ETag)
+	w.Header().Set("x-oss-hash-crc64ecma", strconv.FormatUint(meta.CRC64, 10))
+	e.writeXML(w, http.StatusOK, emulatorCompleteResult{
+		Location: fmt.Sprintf("http://%s/%s/%s", r.Host, bucket, key),
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     meta.ETag,
+	})
+}
+
+func (e *ossEmulator) listMultipartUploads(w http.ResponseWriter, r *http.Request, bucket string) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	keyMarker := query.Get("key-marker")
+	uploadIDMarker := query.Get("upload-id-marker")
+	encodeKeys := query.Get("encoding-type") == "url"
+	maxUploads := emulatorMaxKeys
+	if value := query.Get("max-uploads"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > emulatorMaxKeys {
+			e.writeError(w, r, http.StatusBadRequest, "InvalidArgument", "invalid max-uploads", "")
+			return
+		}
+		maxUploads = parsed
+	}
+
+	entries, err := os.ReadDir(filepath.Join(e.config.Root, emulatorUploadDir))
+	if err != nil && !os.IsNotExist(err) {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), "")
+		return
+	}
+
+	type pendingUpload struct {
+		id     string
+		upload *emulatorUpload
+	}
+	var uploads []pendingUpload
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(e.uploadPath(entry.Name()), "upload.json"))
+		if err != nil {
+			continue
+		}
+		upload := &emulatorUpload{}
+		if json.Unmarshal(content, upload) != nil || upload.Bucket != bucket || !strings.HasPrefix(upload.Key, prefix) {
+			continue
+		}
+		if upload.Key < keyMarker || (upload.Key == keyMarker && (uploadIDMarker == "" || entry.Name() <= uploadIDMarker)) {
+			continue
+		}
+		uploads = append(uploads, pendingUpload{id: entry.Name(), upload: upload})
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		if uploads[i].upload.Key != uploads[j].upload.Key {
+			return uploads[i].upload.Key < uploads[j].upload.Key
+		}
+		return uploads[i].id < uploads[j].id
+	})
+
+	encode := func(s string) string {
+		if encodeKeys {
+			return url.QueryEscape(s)
+		}
+		return s
+	}
+	result := emulatorListUploadsResult{
+		Bucket:         bucket,
+		Prefix:         encode(prefix),
+		KeyMarker:      encode(keyMarker),
+		UploadIDMarker: uploadIDMarker,
+		MaxUploads:     maxUploads,
+	}
+	for i, pending := range uploads {
+		if i >= maxUploads {
+			result.IsTruncated = true
+			last := uploads[i-1]
+			result.NextKeyMarker = encode(last.upload.Key)
+			result.NextUploadIDMarker = last.id
+			break
+		}
+		result.Uploads = append(result.Uploads, emulatorUploadEntry{
+			Key:       encode(pending.upload.Key),
+			UploadID:  pending.id,
+			Initiated: pending.upload.Initiated.Format("2006-01-02T15:04:05.000Z"),
+		})
+	}
+	e.writeXML(w, http.StatusOK, result)
+}
+
+func (e *ossEmulator) listParts(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	if _, err := e.loadUpload(uploadID, bucket, key); err != nil {
+		e.writeError(w, r, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.", key)
+		return
+	}
+	marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+	maxParts := emulatorMaxKeys
+	if value, err := strconv.Atoi(query.Get("max-parts")); err == nil && value > 0 && value <= emulatorMaxKeys {
+		maxParts = value
+	}
+
+	entries, err := os.ReadDir(e.uploadPath(uploadID))
+	if err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+
+	encodedKey := key
+	if query.Get("encoding-type") == "url" {
+		encodedKey = url.QueryEscape(key)
+	}
+	result := emulatorListPartsResult{Bucket: bucket, Key: encodedKey, UploadID: uploadID, MaxParts: maxParts}
+	for _, entry := range entries {
+		var partNumber int
+		if _, err := fmt.Sscanf(entry.Name(), "%05d.part", &partNumber); err != nil || partNumber <= marker {
+			continue
+		}
+		if len(result.Parts) >= maxParts {
+			result.IsTruncated = true
+			break
+		}
+		path := filepath.Join(e.uploadPath(uploadID), entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		info, _ := entry.Info()
+		result.Parts = append(result.Parts, emulatorPartEntry{
+			PartNumber:   partNumber,
+			LastModified: info.ModTime().UTC().Format("2006-01-02T15:04:05.000Z"),
+			ETag:         fmt.Sprintf("\"%X\"", md5.Sum(content)),
+			Size:         int64(len(content)),
+		})
+		result.NextPartNumberMarker = partNumber
+	}
+	e.writeXML(w, http.StatusOK, result)
+}
+
+func (e *ossEmulator) abortMultipart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	uploadID := r.URL.Query().Get("uploadId")
+	if _, err := e.loadUpload(uploadID, bucket, key); err != nil {
+		e.writeError(w, r, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.", key)
+		return
+	}
+	os.RemoveAll(e.uploadPath(uploadID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 解析x-oss-copy-source头: /bucket/urlencoded-key
+func (e *ossEmulator) copySource(r *http.Request) (string, string, error) {
+	source := strings.TrimPrefix(r.Header.Get("x-oss-copy-source"), "/")
+	if idx := strings.Index(source, "?"); idx >= 0 {
+		source = source[:idx]
+	}
+	parts := strings.SplitN(source, "/", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid x-oss-copy-source")
+	}
+	key, err := url.QueryUnescape(parts[1])
+	if err != nil || !isValidEmulatorBucket(parts[0]) || !isValidEmulatorKey(key) {
+		return "", "", fmt.Errorf("invalid x-oss-copy-source")
+	}
+	return parts[0], key, nil
+}
+
+func (e *ossEmulator) copyObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	srcBucket, srcKey, err := e.copySource(r)
+	if err != nil {
+		e.writeError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error(), key)
+		return
+	}
+	srcMeta, err := e.loadMeta(srcBucket, srcKey)
+	if err != nil {
+		e.writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.", srcKey)
+		return
+	}
+	src, err := os.Open(e.objectPath(srcBucket, srcKey))
+	if err != nil {
+		e.writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.", srcKey)
+		return
+	}
+	defer src.Close()
+
+	dataPath := e.objectPath(bucket, key)
+	tmpPath, _, _, _, err := writeEmulatorTempFile(filepath.Dir(dataPath), src)
+	if err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+
+	meta := *srcMeta
+	meta.LastModified = time.Now().UTC()
+	if strings.EqualFold(r.Header.Get("x-oss-metadata-directive"), "REPLACE") {
+		meta.ContentType = emulatorContentType(r)
+		meta.Headers = emulatorObjectHeaders(r)
+	}
+
+	e.mu.Lock()
+	err = os.Rename(tmpPath, dataPath)
+	if err == nil {
+		err = e.saveMeta(bucket, key, &meta)
+	}
+	e.mu.Unlock()
+	if err != nil {
+		os.Remove(tmpPath)
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+
+	e.writeXML(w, http.StatusOK, emulatorCopyResult{
+		XMLName:      xml.Name{Local: "CopyObjectResult"},
+		LastModified: meta.LastModified.Format("2006-01-02T15:04:05.000Z"),
+		ETag:         meta.ETag,
+	})
+}
+
+func (e *ossEmulator) uploadPartCopy(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	partNumber, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > 10000 {
+		e.writeError(w, r, http.StatusBadRequest, "InvalidArgument", "invalid partNumber", key)
+		return
+	}
+	if _, err := e.loadUpload(uploadID, bucket, key); err != nil {
+		e.writeError(w, r, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.", key)
+		return
+	}
+	srcBucket, srcKey, err := e.copySource(r)
+	if err != nil {
+		e.writeError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error(), key)
+		return
+	}
+	src, err := os.Open(e.objectPath(srcBucket, srcKey))
+	if err != nil {
+		e.writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.", srcKey)
+		return
+	}
+	defer src.Close()
+	stat, err := src.Stat()
+	if err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+
+	start, end := int64(0), stat.Size()-1
+	if rangeHeader := r.Header.Get("x-oss-copy-source-range"); rangeHeader != "" {
+		if _, err := fmt.Sscanf(strings.TrimPrefix(rangeHeader, "bytes="), "%d-%d", &start, &end); err != nil ||
+			start < 0 || end < start || end >= stat.Size() {
+			e.writeError(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "invalid copy source range", key)
+			return
+		}
+	}
+
+	dir := e.uploadPath(uploadID)
+	tmpPath, sum, _, _, err := writeEmulatorTempFile(dir, io.NewSectionReader(src, start, end-start+1))
+	if err != nil {
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+	if err := os.Rename(tmpPath, filepath.Join(dir, fmt.Sprintf("%05d.part", partNumber))); err != nil {
+		os.Remove(tmpPath)
+		e.writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error(), key)
+		return
+	}
+
+	e.writeXML(w, http.StatusOK, emulatorCopyResult{
+		XMLName:      xml.Name{Local: "CopyPartResult"},
+		LastModified: time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+		ETag:         fmt.Sprintf("\"%X\"", sum),
+	})
+}
+
+func (e *ossEmulator) writeXML(w http.ResponseWriter, status int, v interface{}) {
+	content, err := xml.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(xml.Header)+len(content)))
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	w.Write(content)
+}
+
+func (e *ossEmulator) writeError(w http.ResponseWriter, r *http.Request, status int, code, message, key string) {
+	io.Copy(io.Discard, r.Body)
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	e.writeXML(w, status, emulatorError{
+		Code:      code,
+		Message:   message,
+		RequestID: w.Header().Get("x-oss-request-id"),
+		HostID:    r.Host,
+		Key:       key,
+	})
+}
+
+type emulatorStatusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *emulatorStatusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func newEmulatorID(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return strings.ToUpper(hex.EncodeToString(buf))
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	mathrand "math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+func TestParseServeConfig(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    *EmulatorConfig
+		wantErr bool
+	}{
+		{nil, &EmulatorConfig{Addr: "127.0.0.1:9000", Root: "./oss_data"}, false},
+		{[]string{"--addr", ":19000", "--root", "/tmp/oss", "-q"}, &EmulatorConfig{Addr: ":19000", Root: "/tmp/oss", Quiet: true}, false},
+		{[]string{"--latency", "200ms", "--error-rate", "0.05", "--drop-rate", "1"},
+			&EmulatorConfig{Addr: "127.0.0.1:9000", Root: "./oss_data", Latency: 200 * time.Millisecond, ErrorRate: 0.05, DropRate: 1}, false},
+		{[]string{"--latency", "200"}, nil, true},
+		{[]string{"--error-rate", "1.5"}, nil, true},
+		{[]string{"--drop-rate", "-0.1"}, nil, true},
+		{[]string{"--root"}, nil, true},
+		{[]string{"--bogus"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseServeConfig(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseServeConfig(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseServeConfig(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestEmulatorNames(t *testing.T) {
+	buckets := []struct {
+		bucket string
+		want   bool
+	}{
+		{"bkt", true},
+		{"my-bucket-01", true},
+		{"ab", false},
+		{"-bkt", false},
+		{"bkt-", false},
+		{"My-Bucket", false},
+		{"bkt_1", false},
+	}
+	for _, tt := range buckets {
+		if got := isValidEmulatorBucket(tt.bucket); got != tt.want {
+			t.Errorf("isValidEmulatorBucket(%q) = %v, want %v", tt.bucket, got, tt.want)
+		}
+	}
+
+	keys := []struct {
+		key  string
+		want bool
+	}{
+		{"a.txt", true},
+		{"releases/v1/", true},
+		{"图片/a b.png", true},
+		{"/a.txt", false},
+		{"a/../b", false},
+		{"./a", false},
+		{`a\b`, false},
+		{"a/" + emulatorDirMarker, false},
+	}
+	for _, tt := range keys {
+		if got := isValidEmulatorKey(tt.key); got != tt.want {
+			t.Errorf("isValidEmulatorKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestEmulatorSplitRequestPath(t *testing.T) {
+	tests := []struct {
+		host       string
+		path       string
+		wantBucket string
+		wantKey    string
+	}{
+		{"127.0.0.1:9000", "/bkt/a/b.txt", "bkt", "a/b.txt"},
+		{"127.0.0.1:9000", "/bkt", "bkt", ""},
+		{"127.0.0.1:9000", "/bkt/", "bkt", ""},
+		{"localhost:9000", "/bkt/a.txt", "bkt", "a.txt"},
+		{"bkt.oss.local:9000", "/a/b.txt", "bkt", "a/b.txt"},
+		{"bkt.oss.local", "/", "bkt", ""},
+		{"127.0.0.1:9000", "/", "", ""},
+	}
+	e := &ossEmulator{config: &EmulatorConfig{}}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://"+tt.host+tt.path, nil)
+		bucket, key := e.splitRequestPath(r)
+		if bucket != tt.wantBucket || key != tt.wantKey {
+			t.Errorf("splitRequestPath(%s%s) = %q, %q, want %q, %q", tt.host, tt.path, bucket, key, tt.wantBucket, tt.wantKey)
+		}
+	}
+}
+
+// 启动模拟服务并返回连接到它的bucket
+func newTestEmulator(t *testing.T) *oss.Bucket {
+	t.Helper()
+	emulator := &ossEmulator{
+		config: &EmulatorConfig{Root: t.TempDir(), Quiet: true},
+		rnd:    mathrand.New(mathrand.NewSource(1)),
+	}
+	server := httptest.NewServer(emulator)
+	t.Cleanup(server.Close)
+
+	client, err := oss.New(server.URL, "test", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucket, err := client.Bucket("bkt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bucket
+}
+
+func TestEmulatorObjects(t *testing.T) {
+	bucket := newTestEmulator(t)
+
+	for _, key := range []string{"releases/v1/app.apk", "releases/v1/notes.txt", "releases/v10/app.apk", "static/a.js"} {
+		if err := bucket.PutObject(key, bytes.NewReader([]byte("content of "+key)), oss.ContentType("text/plain")); err != nil {
+			t.Fatalf("PutObject(%s): %v", key, err)
+		}
+	}
+
+	// Range读取
+	body, err := bucket.GetObject("static/a.js", oss.Range(0, 9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "content of" {
+		t.Errorf("GetObject() with range = %q, want %q", data, "content of")
+	}
+
+	header, err := bucket.GetObjectDetailedMeta("static/a.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Content-Type") != "text/plain" || header.Get("Content-Length") != "22" || header.Get(oss.HTTPHeaderOssCRC64) == "" {
+		t.Errorf("HeadObject() headers = %v", header)
+	}
+
+	tests := []struct {
+		name         string
+		options      []oss.Option
+		wantKeys     []string
+		wantPrefixes []string
+	}{
+		{"all", nil, []string{"releases/v1/app.apk", "releases/v1/notes.txt", "releases/v10/app.apk", "static/a.js"}, nil},
+		{"prefix", []oss.Option{oss.Prefix("releases/v1/")}, []string{"releases/v1/app.apk", "releases/v1/notes.txt"}, nil},
+		{"delimiter", []oss.Option{oss.Delimiter("/")}, nil, []string{"releases/", "static/"}},
+		{"prefix and delimiter", []oss.Option{oss.Prefix("releases/"), oss.Delimiter("/")}, nil, []string{"releases/v1/", "releases/v10/"}},
+		{"marker", []oss.Option{oss.Marker("releases/v10/app.apk")}, []string{"static/a.js"}, nil},
+	}
+	for _, tt := range tests {
+		result, err := bucket.ListObjects(tt.options...)
+		if err != nil {
+			t.Errorf("%s: ListObjects() error = %v", tt.name, err)
+			continue
+		}
+		var keys []string
+		for _, object := range result.Objects {
+			keys = append(keys, object.Key)
+		}
+		if !reflect.DeepEqual(keys, tt.wantKeys) || !reflect.DeepEqual(result.CommonPrefixes, tt.wantPrefixes) {
+			t.Errorf("%s: ListObjects() = %q %q, want %q %q", tt.name, keys, result.CommonPrefixes, tt.wantKeys, tt.wantPrefixes)
+		}
+	}
+
+	// 分页
+	page, err := bucket.ListObjects(oss.MaxKeys(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Objects) != 3 || !page.IsTruncated || page.NextMarker != "releases/v10/app.apk" {
+		t.Errorf("ListObjects(MaxKeys 3) = %d objects, truncated %v, next %q", len(page.Objects), page.IsTruncated, page.NextMarker)
+	}
+
+	if _, err := bucket.CopyObject("static/a.js", "static/b.js"); err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := bucket.DeleteObjects([]string{"static/a.js", "missing.js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted.DeletedObjects) != 2 {
+		t.Errorf("DeleteObjects() deleted %q, want both keys", deleted.DeletedObjects)
+	}
+	if exists, _ := bucket.IsObjectExist("static/a.js"); exists {
+		t.Errorf("static/a.js still exists after DeleteObjects()")
+	}
+	if exists, _ := bucket.IsObjectExist("static/b.js"); !exists {
+		t.Errorf("copied object static/b.js does not exist")
+	}
+
+	_, err = bucket.GetObject("missing.js")
+	if serviceErr, ok := err.(oss.ServiceError); !ok || serviceErr.StatusCode != 404 || serviceErr.Code != "NoSuchKey" {
+		t.Errorf("GetObject(missing) error = %v, want NoSuchKey", err)
+	}
+}
+
+func TestEmulatorMultipart(t *testing.T) {
+	bucket := newTestEmulator(t)
+
+	// 3个分片，最后一个不满
+	content := bytes.Repeat([]byte("0123456789"), 25*1024)
+	localFile := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(localFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 各分片使用自己的响应头，respHeader最后是CompleteMultipartUpload的响应
+	var respHeader http.Header
+	if err := bucket.UploadFile("big.bin", localFile, 100*1024, oss.Routines(2), oss.GetResponseHeader(&respHeader)); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := bucket.GetObjectMeta("big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := respHeader.Get(oss.HTTPHeaderEtag), meta.Get(oss.HTTPHeaderEtag); got == "" || got != want {
+		t.Errorf("complete response ETag = %q, want %q", got, want)
+	}
+
+	body, err := bucket.GetObject("big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if !bytes.Equal(data, content) {
+		t.Errorf("multipart object has %d bytes, want %d", len(data), len(content))
+	}
+
+	// 未完成的上传可以列出和中止
+	imur, err := bucket.InitiateMultipartUpload("pending.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads, err := bucket.ListMultipartUploads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploads.Uploads) != 1 || uploads.Uploads[0].UploadID != imur.UploadID {
+		t.Errorf("ListMultipartUploads() = %+v, want the pending upload", uploads.Uploads)
+	}
+	if err := bucket.AbortMultipartUpload(imur); err != nil {
+		t.Fatal(err)
+	}
+	err = bucket.AbortMultipartUpload(imur)
+	if serviceErr, ok := err.(oss.ServiceError); !ok || serviceErr.Code != "NoSuchUpload" {
+		t.Errorf("second AbortMultipartUpload() error = %v, want NoSuchUpload", err)
+	}
+}
+
+func TestEmulatorPresignedExpiry(t *testing.T) {
+	bucket := newTestEmulator(t)
+	if err := bucket.PutObject("a.txt", bytes.NewReader([]byte("a"))); err != nil {
+		t.Fatal(err)
+	}
+
+	signedURL, err := bucket.SignURL("a.txt", oss.HTTPGet, 3600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 模拟服务不校验签名，直接改写过期时间
+	expired, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := expired.Query()
+	query.Set("Expires", strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+	expired.RawQuery = query.Encode()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+	}{
+		{"valid", signedURL, http.StatusOK},
+		{"expired", expired.String(), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.wantStatus)
+		}
+	}
+}
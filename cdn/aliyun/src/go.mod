@@ -2,7 +2,10 @@ module oss-ultra-fast
 
 go 1.19
 
-require github.com/aliyun/aliyun-oss-go-sdk v3.0.2+incompatible
+require (
+	github.com/aliyun/aliyun-oss-go-sdk v3.0.2+incompatible
+	golang.org/x/text v0.14.0
+)
 
 require (
 	golang.org/x/time v0.3.0 // indirect
@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLine      = 1024 * 1024 // 超过的行视为损坏并跳过
+)
+
+// 上传历史中的一次运行，文件逐个写入后追加一行type为run的汇总
+// 监听模式下首次上传和之后的每批同步各记录一次运行
+type historyRecord struct {
+	Type     string    `json:"type"` // run
+	Run      string    `json:"run"`
+	Time     time.Time `json:"time"`
+	Finished time.Time `json:"finished"`
+	Command  []string  `json:"command"`
+	Profile  string    `json:"profile"` // 凭证来源: env 或配置文件路径
+	Endpoint string    `json:"endpoint"`
+	Bucket   string    `json:"bucket"`
+	User     string    `json:"user"`
+	Host     string    `json:"host"`
+	Mode     string    `json:"mode"` // file, directory, manifest, watch
+	Files    int       `json:"files"`
+	Failed   int       `json:"failed"`
+	Bytes    int64     `json:"bytes"`
+	Result   string    `json:"result"` // success, failed, interrupted
+	Error    string    `json:"error,omitempty"`
+}
+
+// 每个文件一行，用run关联所属的运行
+type historyFile struct {
+	Type   string    `json:"type"` // file
+	Run    string    `json:"run"`
+	Time   time.Time `json:"time"`
+	Local  string    `json:"local,omitempty"`
+	Key    string    `json:"key"`
+	Size   int64     `json:"size"`
+	ETag   string    `json:"etag,omitempty"`
+	Result string    `json:"result"` // uploaded, deduped, deleted, failed, interrupted
+	Error  string    `json:"error,omitempty"`
+}
+
+// 上传历史记录器，nil表示不记录
+type uploadHistory struct {
+	mutex   sync.Mutex
+	path    string
+	record  historyRecord
+	flushed bool
+}
+
+// 运行ID: 开始时间加随机后缀，多台机器同时写同一个历史文件也不会冲突
+func newHistoryRunID(t time.Time) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return t.Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// 历史文件位置，可用环境变量OSS_ULTRA_HISTORY指定
+func historyPath() (string, error) {
+	if path := os.Getenv("OSS_ULTRA_HISTORY"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "oss_ultra_fast", "history.jsonl"), nil
+}
+
+func newUploadHistory(config *UltraConfig) *uploadHistory {
+	path, err := historyPath()
+	if err != nil {
+		fmt.Printf("⚠️  无法确定上传历史位置，本次不记录: %v\n", err)
+		return nil
+	}
+
+	mode := "file"
+	if config.ManifestFile != "" {
+		mode = "manifest"
+	} else if config.IsDirectory {
+		mode = "directory"
+	}
+	host, _ := os.Hostname()
+
+	now := time.Now()
+	return &uploadHistory{
+		path: path,
+		record: historyRecord{
+			Type:     "run",
+			Run:      newHistoryRunID(now),
+			Time:     now,
+			Command:  os.Args,
+			Profile:  config.ConfigSource,
+			Endpoint: config.Endpoint,
+			Bucket:   config.BucketName,
+			User:     currentUser(),
+			Host:     host,
+			Mode:     mode,
+		},
+	}
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// 立即追加一个文件的上传结果，运行汇总只保留计数
+func (h *uploadHistory) observeFile(file historyFile, err error) {
+	if h == nil {
+		return
+	}
+	switch {
+	case errors.Is(err, errInterrupted):
+		file.Result = "interrupted"
+	case err != nil:
+		file.Result = "failed"
+		file.Error = err.Error()
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	file.Type = "file"
+	file.Run = h.record.Run
+	file.Time = time.Now()
+	if err := appendHistory(h.path, file); err != nil {
+		fmt.Printf("⚠️  写入上传历史失败: %v\n", err)
+	}
+	h.record.Files++
+	switch file.Result {
+	case "uploaded", "deduped":
+		h.record.Bytes += file.Size
+	case "failed":
+		h.record.Failed++
+	}
+}
+
+// 追加本次记录并开始新的一段，如监听模式的下一批同步
+// 没有文件的记录只在第一次写入，保证每次运行至少有一行
+func (h *uploadHistory) flush(mode string, err error) {
+	if h == nil {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.flushed && h.record.Files == 0 {
+		return
+	}
+	record := h.record
+	if mode != "" {
+		record.Mode = mode
+	}
+	record.Finished = time.Now()
+	record.Result = "success"
+	if errors.Is(err, errInterrupted) {
+		record.Result = "interrupted"
+	} else if err != nil {
+		record.Result = "failed"
+		record.Error = err.Error()
+	} else if record.Failed > 0 {
+		record.Result = "failed"
+	}
+
+	if err := appendHistory(h.path, record); err != nil {
+		fmt.Printf("⚠️  写入上传历史失败: %v\n", err)
+	}
+	h.flushed = true
+	h.record.Time = time.Now()
+	h.record.Run = newHistoryRunID(h.record.Time)
+	h.record.Files = 0
+	h.record.Failed = 0
+	h.record.Bytes = 0
+}
+
+// 以追加方式写入一行，单次write保证多个进程同时写入时不会交错
+func appendHistory(path string, entry interface{}) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// history 命令的查询条件
+type historyQuery struct {
+	File   string
+	Since  time.Time
+	Until  time.Time
+	Prefix string
+	Key    string
+	Failed bool
+	Limit  int
+	JSON   bool
+}
+
+func showHistoryUsage() {
+	fmt.Printf(`查询上传历史 - 谁在什么时候从哪台机器上传了什么
+
+用法: %s history [选项]
+
+选项:
+  --since TIME     起始时间，如2024-05-01、"2024-05-01 08:00"、24h、7d
+  --until TIME     结束时间，格式同上
+  --prefix PREFIX  只显示上传了该前缀下对象的运行
+  --key KEY        只显示上传了该对象的运行
+  --failed         只显示失败或中断的运行
+  -n, --limit N    最多显示最近N次运行，默认%d，0为不限制
+  --json           输出原始JSON行
+  --file FILE      历史文件，默认%s
+  -h               帮助
+
+示例:
+  %s history --key static/app.js
+  %s history --prefix releases/v1.0/ --since 7d
+  %s history --failed --since "2024-05-01 08:00" --until "2024-05-01 12:00"
+`, os.Args[0], defaultHistoryLimit, displayHistoryPath(), os.Args[0], os.Args[0], os.Args[0])
+}
+
+func displayHistoryPath() string {
+	path, err := historyPath()
+	if err != nil {
+		return "(未知)"
+	}
+	return path
+}
+
+// 解析时间: 日期、日期时间、RFC3339，或24h、7d表示距今多久
+func parseHistoryTime(value string) (time.Time, error) {
+	if ago, err := parseTTL(value); err == nil {
+		return time.Now().Add(-ago), nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无效的时间: %s", value)
+}
+
+func parseHistoryQuery(args []string) (*historyQuery, error) {
+	query := &historyQuery{Limit: defaultHistoryLimit}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--failed":
+			query.Failed = true
+		case "--json":
+			query.JSON = true
+		case "-h", "--help":
+			showHistoryUsage()
+			os.Exit(0)
+		case "--since", "--until", "--prefix", "--key", "-n", "--limit", "--file":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s 需要参数", arg)
+			}
+			value := args[i+1]
+			i++
+			switch arg {
+			case "--since", "--until":
+				t, err := parseHistoryTime(value)
+				if err != nil {
+					return nil, err
+				}
+				if arg == "--since" {
+					query.Since = t
+				} else {
+					// 只给日期时包含当天
+					if len(value) == len("2006-01-02") && !strings.HasSuffix(value, "d") {
+						t = t.Add(24 * time.Hour)
+					}
+					query.Until = t
+				}
+			case "--prefix":
+				query.Prefix = strings.TrimPrefix(value, "/")
+			case "--key":
+				query.Key = strings.TrimPrefix(value, "/")
+			case "--file":
+				query.File = value
+			default:
+				limit, err := strconv.Atoi(value)
+				if err != nil || limit < 0 {
+					return nil, fmt.Errorf("无效的数量: %s", value)
+				}
+				query.Limit = limit
+			}
+		default:
+			return nil, fmt.Errorf("未知参数: %s", arg)
+		}
+	}
+	return query, nil
+}
+
+// 按对象过滤: --prefix 或 --key
+func (q *historyQuery) filtersFiles() bool {
+	return q.Prefix != "" || q.Key != ""
+}
+
+func (q *historyQuery) matchFile(file historyFile) bool {
+	return (q.Key == "" || file.Key == q.Key) && strings.HasPrefix(file.Key, q.Prefix)
+}
+
+// 需要保留下来显示的文件: 按对象查询时列出匹配的文件，否则只列出失败的文件
+func (q *historyQuery) showFile(file historyFile) bool {
+	if !q.matchFile(file) {
+		return false
+	}
+	if q.JSON || q.filtersFiles() {
+		return true
+	}
+	return file.Result != "uploaded" && file.Result != "deduped" && file.Result != "deleted"
+}
+
+// 读取中的一次运行，文件行在运行汇总行之前
+type historyRun struct {
+	record    historyRecord
+	line      string
+	files     []historyFile
+	fileLines []string
+	matched   bool // 有文件符合 --prefix/--key
+}
+
+func (q *historyQuery) match(run *historyRun) bool {
+	record := run.record
+	if !q.Since.IsZero() && record.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !record.Time.Before(q.Until) {
+		return false
+	}
+	if q.Failed && record.Result == "success" {
+		return false
+	}
+	return !q.filtersFiles() || run.matched
+}
+
+// 逐行读取历史，超长的行跳过而不是中止整个查询
+func scanHistoryLines(r io.Reader, fn func(lineNo int, line []byte)) error {
+	reader := bufio.NewReader(r)
+	var line []byte
+	lineNo, tooLong := 0, false
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+			if len(line) > maxHistoryLine {
+				tooLong = true
+			}
+		}
+		if isPrefix {
+			continue
+		}
+		lineNo++
+		if tooLong {
+			fmt.Printf("⚠️  跳过第%d行: 超过%s\n", lineNo, humanSize(maxHistoryLine))
+		} else {
+			fn(lineNo, line)
+		}
+		line, tooLong = line[:0], false
+	}
+}
+
+func runHistory(args []string) error {
+	query, err := parseHistoryQuery(args)
+	if err != nil {
+		return err
+	}
+	if query.File == "" {
+		if query.File, err = historyPath(); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(query.File)
+	if os.IsNotExist(err) {
+		fmt.Printf("暂无上传历史 (%s)\n", query.File)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// 历史按时间追加，保留最近的匹配项；还没有汇总行的运行暂存在pending中
+	pending := map[string]*historyRun{}
+	var runs []*historyRun
+	err = scanHistoryLines(file, func(lineNo int, data []byte) {
+		line := strings.TrimSpace(string(data))
+		if line == "" {
+			return
+		}
+		var head struct {
+			Type string `json:"type"`
+			Run  string `json:"run"`
+		}
+		if err := json.Unmarshal([]byte(line), &head); err != nil {
+			fmt.Printf("⚠️  跳过第%d行: %v\n", lineNo, err)
+			return
+		}
+
+		run := pending[head.Run]
+		if run == nil {
+			run = &historyRun{}
+		}
+		switch head.Type {
+		case "file":
+			var entry historyFile
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				fmt.Printf("⚠️  跳过第%d行: %v\n", lineNo, err)
+				return
+			}
+			pending[head.Run] = run
+			if query.filtersFiles() && query.matchFile(entry) {
+				run.matched = true
+			}
+			if query.showFile(entry) {
+				run.files = append(run.files, entry)
+				run.fileLines = append(run.fileLines, line)
+			}
+		case "run":
+			if err := json.Unmarshal([]byte(line), &run.record); err != nil {
+				fmt.Printf("⚠️  跳过第%d行: %v\n", lineNo, err)
+				return
+			}
+			delete(pending, head.Run)
+			run.line = line
+			if !query.match(run) {
+				return
+			}
+			runs = append(runs, run)
+			if query.Limit > 0 && len(runs) > query.Limit {
+				runs = runs[1:]
+			}
+		default:
+			fmt.Printf("⚠️  跳过第%d行: 未知的记录类型 %q\n", lineNo, head.Type)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("读取上传历史失败: %v", err)
+	}
+
+	if query.JSON {
+		for _, run := range runs {
+			fmt.Println(run.line)
+			for _, line := range run.fileLines {
+				fmt.Println(line)
+			}
+		}
+		return nil
+	}
+	if len(runs) == 0 {
+		fmt.Printf("没有匹配的上传记录\n")
+		return nil
+	}
+
+	for _, run := range runs {
+		printHistoryRun(run)
+	}
+	fmt.Printf("\n共 %d 次运行\n", len(runs))
+	return nil
+}
+
+func printHistoryRun(run *historyRun) {
+	record := run.record
+	icon := "✅"
+	switch record.Result {
+	case "failed":
+		icon = "❌"
+	case "interrupted":
+		icon = "⏸️ "
+	}
+	fmt.Printf("\n%s %s  %s@%s  %s  %d 个文件, %s, 耗时%.1f秒\n",
+		icon, record.Time.Local().Format("2006-01-02 15:04:05"), record.User, record.Host,
+		record.Mode, record.Files, humanSize(record.Bytes), record.Finished.Sub(record.Time).Seconds())
+	fmt.Printf("   命令: %s\n", strings.Join(record.Command, " "))
+	fmt.Printf("   目标: oss://%s (%s, 凭证: %s)\n", record.Bucket, record.Endpoint, record.Profile)
+	if record.Error != "" {
+		fmt.Printf("   错误: %s\n", record.Error)
+	}
+
+	for _, file := range run.files {
+		line := fmt.Sprintf("   %-11s %s (%s", file.Result, file.Key, humanSize(file.Size))
+		if file.ETag != "" {
+			line += ", ETag " + file.ETag
+		}
+		line += ")"
+		if file.Error != "" {
+			line += " " + file.Error
+		}
+		fmt.Println(line)
+	}
+}
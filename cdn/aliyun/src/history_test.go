@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		value   string
+		want    time.Time
+		approx  bool // 相对时间，允许几秒误差
+		wantErr bool
+	}{
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local), false, false},
+		{"2024-05-01 08:30", time.Date(2024, 5, 1, 8, 30, 0, 0, time.Local), false, false},
+		{"2024-05-01 08:30:15", time.Date(2024, 5, 1, 8, 30, 15, 0, time.Local), false, false},
+		{"2024-05-01T08:30:15Z", time.Date(2024, 5, 1, 8, 30, 15, 0, time.UTC), false, false},
+		{"24h", now.Add(-24 * time.Hour), true, false},
+		{"7d", now.Add(-7 * 24 * time.Hour), true, false},
+		{"2024/05/01", time.Time{}, false, true},
+		{"yesterday", time.Time{}, false, true},
+	}
+	for _, tt := range tests {
+		got, err := parseHistoryTime(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseHistoryTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		diff := got.Sub(tt.want)
+		if (tt.approx && (diff < 0 || diff > 5*time.Second)) || (!tt.approx && !got.Equal(tt.want)) {
+			t.Errorf("parseHistoryTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseHistoryQuery(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		args    []string
+		want    *historyQuery
+		wantErr bool
+	}{
+		{nil, &historyQuery{Limit: defaultHistoryLimit}, false},
+		{[]string{"--key", "/static/app.js", "--failed", "--json"}, &historyQuery{Key: "static/app.js", Failed: true, JSON: true, Limit: defaultHistoryLimit}, false},
+		{[]string{"--prefix", "releases/", "-n", "0", "--file", "h.jsonl"}, &historyQuery{Prefix: "releases/", File: "h.jsonl"}, false},
+		// 只给日期时--until包含当天
+		{[]string{"--since", "2024-05-01", "--until", "2024-05-01"}, &historyQuery{Since: day, Until: day.Add(24 * time.Hour), Limit: defaultHistoryLimit}, false},
+		{[]string{"--until", "2024-05-01 12:00"}, &historyQuery{Until: day.Add(12 * time.Hour), Limit: defaultHistoryLimit}, false},
+		{[]string{"--limit", "-1"}, nil, true},
+		{[]string{"--since"}, nil, true},
+		{[]string{"--since", "soon"}, nil, true},
+		{[]string{"--bogus"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseHistoryQuery(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseHistoryQuery(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseHistoryQuery(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestHistoryQueryMatch(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	files := []historyFile{
+		{Key: "releases/v1/app.apk", Result: "uploaded"},
+		{Key: "releases/v1/notes.txt", Result: "failed"},
+		{Key: "static/app.js", Result: "uploaded"},
+	}
+
+	tests := []struct {
+		name      string
+		query     historyQuery
+		result    string
+		want      bool
+		wantFiles int // showFile保留的文件数
+	}{
+		{"no filter", historyQuery{}, "success", true, 1},
+		{"json keeps all files", historyQuery{JSON: true}, "success", true, 3},
+		{"since before", historyQuery{Since: t0.Add(-time.Hour)}, "success", true, 1},
+		{"since after", historyQuery{Since: t0.Add(time.Second)}, "success", false, 1},
+		{"since equal", historyQuery{Since: t0}, "success", true, 1},
+		{"until equal", historyQuery{Until: t0}, "success", false, 1},
+		{"until after", historyQuery{Until: t0.Add(time.Second)}, "success", true, 1},
+		{"failed only", historyQuery{Failed: true}, "success", false, 1},
+		{"failed run", historyQuery{Failed: true}, "failed", true, 1},
+		{"prefix", historyQuery{Prefix: "releases/v1/"}, "success", true, 2},
+		{"prefix without match", historyQuery{Prefix: "releases/v2/"}, "success", false, 0},
+		{"key", historyQuery{Key: "static/app.js"}, "success", true, 1},
+		{"key is not a prefix", historyQuery{Key: "static/app"}, "success", false, 0},
+	}
+	for _, tt := range tests {
+		run := &historyRun{record: historyRecord{Time: t0, Result: tt.result}}
+		shown := 0
+		for _, file := range files {
+			if tt.query.filtersFiles() && tt.query.matchFile(file) {
+				run.matched = true
+			}
+			if tt.query.showFile(file) {
+				shown++
+			}
+		}
+		if got := tt.query.match(run); got != tt.want {
+			t.Errorf("%s: match() = %v, want %v", tt.name, got, tt.want)
+		}
+		if shown != tt.wantFiles {
+			t.Errorf("%s: showFile() kept %d files, want %d", tt.name, shown, tt.wantFiles)
+		}
+	}
+}
+
+// 读出历史文件中的运行汇总和文件行
+func readHistory(t *testing.T, path string) ([]historyRecord, []historyFile) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var records []historyRecord
+	var files []historyFile
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var head struct{ Type string }
+		if err := json.Unmarshal(scanner.Bytes(), &head); err != nil {
+			t.Fatalf("history line is not JSON: %v", err)
+		}
+		switch head.Type {
+		case "run":
+			var record historyRecord
+			json.Unmarshal(scanner.Bytes(), &record)
+			records = append(records, record)
+		case "file":
+			var entry historyFile
+			json.Unmarshal(scanner.Bytes(), &entry)
+			files = append(files, entry)
+		default:
+			t.Fatalf("unknown history line type %q", head.Type)
+		}
+	}
+	return records, files
+}
+
+func TestUploadHistoryFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "history.jsonl")
+	history := &uploadHistory{path: path, record: historyRecord{Type: "run", Run: "run-1", Time: time.Now(), Mode: "directory"}}
+
+	history.observeFile(historyFile{Key: "a.js", Size: 10, Result: "uploaded"}, nil)
+	history.observeFile(historyFile{Key: "b.js", Size: 20, Result: "deduped"}, nil)
+	history.observeFile(historyFile{Key: "c.js", Size: 30}, fmt.Errorf("HTTP 403"))
+	history.flush("", nil)
+
+	// 监听模式: 没有变化的批次不记录，中断的批次单独记录
+	history.flush("watch", nil)
+	history.observeFile(historyFile{Key: "d.js", Size: 40}, errInterrupted)
+	history.flush("watch", fmt.Errorf("上传中断: %w", errInterrupted))
+
+	records, files := readHistory(t, path)
+	if len(records) != 2 || len(files) != 4 {
+		t.Fatalf("got %d runs and %d files, want 2 and 4", len(records), len(files))
+	}
+
+	first := records[0]
+	if first.Run != "run-1" || first.Mode != "directory" || first.Result != "failed" || first.Files != 3 || first.Failed != 1 || first.Bytes != 30 {
+		t.Errorf("first record = %+v, want run-1 directory failed with 3 files, 1 failed, 30 bytes", first)
+	}
+	wantResults := []string{"uploaded", "deduped", "failed", "interrupted"}
+	for i, file := range files {
+		if file.Result != wantResults[i] {
+			t.Errorf("file %s result = %s, want %s", file.Key, file.Result, wantResults[i])
+		}
+		wantRun := first.Run
+		if i == 3 {
+			wantRun = records[1].Run
+		}
+		if file.Run != wantRun {
+			t.Errorf("file %s run = %q, want %q", file.Key, file.Run, wantRun)
+		}
+	}
+	if files[2].Error != "HTTP 403" {
+		t.Errorf("failed file error = %q, want HTTP 403", files[2].Error)
+	}
+
+	second := records[1]
+	if second.Run == first.Run || second.Mode != "watch" || second.Result != "interrupted" || second.Files != 1 {
+		t.Errorf("second record = %+v, want one interrupted watch file in a new run", second)
+	}
+}
+
+func TestUploadHistoryFlushEmptyRun(t *testing.T) {
+	// 没有文件的运行也记录一行
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	history := &uploadHistory{path: path, record: historyRecord{Type: "run", Run: "run-1", Time: time.Now(), Mode: "file"}}
+	history.flush("", errors.New("本地文件不存在"))
+
+	records, files := readHistory(t, path)
+	if len(records) != 1 || len(files) != 0 || records[0].Result != "failed" || records[0].Error != "本地文件不存在" {
+		t.Errorf("records = %+v, want one failed run", records)
+	}
+
+	var disabled *uploadHistory
+	disabled.observeFile(historyFile{Key: "a.js"}, nil)
+	disabled.flush("", nil)
+}
+
+func TestScanHistoryLines(t *testing.T) {
+	long := strings.Repeat("x", maxHistoryLine+10)
+	input := "a\n" + long + "\n\nb"
+
+	var got []string
+	err := scanHistoryLines(strings.NewReader(input), func(lineNo int, line []byte) {
+		got = append(got, fmt.Sprintf("%d:%s", lineNo, line))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 超长的第2行被跳过，其余行号不变
+	if want := []string{"1:a", "3:", "4:b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("scanHistoryLines() = %q, want %q", got, want)
+	}
+}
+
+func TestRunHistorySkipsBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	t0 := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	lines := []interface{}{
+		historyFile{Type: "file", Run: "r1", Key: "static/app.js", Result: "uploaded"},
+		historyRecord{Type: "run", Run: "r1", Time: t0, Files: 1, Result: "success"},
+		historyFile{Type: "file", Run: "r2", Key: "other.js", Result: "failed"},
+		historyRecord{Type: "run", Run: "r2", Time: t0, Files: 1, Failed: 1, Result: "failed"},
+	}
+	for _, line := range lines {
+		if err := appendHistory(path, line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 损坏的行、超长的行和未知类型都跳过
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(f, "{broken\n%s\n{\"type\":\"other\"}\n", strings.Repeat("y", maxHistoryLine+1))
+	f.Close()
+
+	out := captureStdout(t, func() {
+		if err := runHistory([]string{"--file", path, "--key", "static/app.js", "--json"}); err != nil {
+			t.Errorf("runHistory() error = %v", err)
+		}
+	})
+	var runs, files int
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, `{"type":"run","run":"r1"`):
+			runs++
+		case strings.HasPrefix(line, `{"type":"file","run":"r1"`):
+			files++
+		case strings.Contains(line, `"r2"`):
+			t.Errorf("unexpected output for r2: %s", line)
+		}
+	}
+	if runs != 1 || files != 1 {
+		t.Errorf("runHistory --key printed %d runs and %d files, want 1 and 1:\n%s", runs, files, out)
+	}
+	if strings.Count(out, "跳过第") != 3 {
+		t.Errorf("want 3 skipped line warnings, got:\n%s", out)
+	}
+}
+
+// 捕获fn期间写到标准输出的内容
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	os.Stdout = stdout
+	w.Close()
+	return <-done
+}
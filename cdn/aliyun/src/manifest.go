@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// JSON清单中的一条记录
+type manifestItem struct {
+	Local   string            `json:"local"`
+	Remote  string            `json:"remote"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// 按清单上传，清单中的相对路径以清单文件所在目录为基准
+func uploadManifest(config *UltraConfig, bucket *oss.Bucket) error {
+	fmt.Printf("🚀 极速清单上传模式启动\n")
+	fmt.Printf("清单: %s\n", config.ManifestFile)
+	fmt.Printf("目标: oss://%s/%s\n", config.BucketName, config.RemoteObject)
+	config.Template.print(config.RemoteTemplate)
+
+	if config.UseAggressive {
+		fmt.Printf("💥 极限模式: %dMB分片, %d并发\n",
+			config.PartSize/1024/1024, config.Routines)
+	}
+
+	items, err := loadManifest(config.ManifestFile)
+	if err != nil {
+		return err
+	}
+
+	entries, err := manifestEntries(config, items)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("📋 清单共 %d 个文件\n", len(entries))
+
+	return uploadEntries(config, bucket, sliceSource(entries), "清单上传")
+}
+
+// 根据扩展名选择格式: .json、.csv，其余按每行 "本地 -> 远程" 解析
+func loadManifest(manifestFile string) ([]manifestItem, error) {
+	data, err := os.ReadFile(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取清单失败: %v", err)
+	}
+
+	var items []manifestItem
+	switch strings.ToLower(filepath.Ext(manifestFile)) {
+	case ".json":
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("解析JSON清单失败: %v", err)
+		}
+	case ".csv":
+		items, err = parseCSVManifest(data)
+	default:
+		items, err = parseLineManifest(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("清单为空: %s", manifestFile)
+	}
+	return items, nil
+}
+
+// 每行一个文件:
+//
+//	dist/app.js -> static/app.js | Cache-Control: max-age=31536000
+//
+// 空行和#开头的行忽略
+func parseLineManifest(data []byte) ([]manifestItem, error) {
+	var items []manifestItem
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "|")
+		pair := strings.SplitN(fields[0], "->", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("清单第%d行格式错误，应为 \"本地 -> 远程\": %s", lineNo, line)
+		}
+
+		item := manifestItem{Local: strings.TrimSpace(pair[0]), Remote: strings.TrimSpace(pair[1])}
+		for _, field := range fields[1:] {
+			if err := item.addHeader(field); err != nil {
+				return nil, fmt.Errorf("清单第%d行: %v", lineNo, err)
+			}
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取清单失败: %v", err)
+	}
+	return items, nil
+}
+
+// CSV清单: 每行 local,remote[,请求头...]
+// 首行为 local,remote,Cache-Control,... 形式的表头时，后续列的值即对应请求头；
+// 没有表头时，额外的列写成 "Name: value"
+func parseCSVManifest(data []byte) ([]manifestItem, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	var headerNames []string
+	var items []manifestItem
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析CSV清单失败: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 {
+			return nil, fmt.Errorf("CSV清单第%d行至少需要 local,remote 两列", line)
+		}
+
+		if items == nil && headerNames == nil && strings.EqualFold(strings.TrimSpace(record[0]), "local") {
+			headerNames = record[2:]
+			continue
+		}
+
+		item := manifestItem{Local: strings.TrimSpace(record[0]), Remote: strings.TrimSpace(record[1])}
+		for column, value := range record[2:] {
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			if column < len(headerNames) {
+				value = headerNames[column] + ":" + value
+			}
+			if err := item.addHeader(value); err != nil {
+				return nil, fmt.Errorf("CSV清单第%d行: %v", line, err)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// 解析 "Name: value" 形式的请求头
+func (item *manifestItem) addHeader(field string) error {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return nil
+	}
+	parts := strings.SplitN(field, ":", 2)
+	name := strings.TrimSpace(parts[0])
+	if len(parts) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("无效的请求头: %s", field)
+	}
+	if item.Headers == nil {
+		item.Headers = map[string]string{}
+	}
+	item.Headers[name] = strings.TrimSpace(parts[1])
+	return nil
+}
+
+// 把清单记录转换为上传任务并检查本地文件
+func manifestEntries(config *UltraConfig, items []manifestItem) ([]uploadEntry, error) {
+	baseDir := filepath.Dir(config.ManifestFile)
+	entries := make([]uploadEntry, 0, len(items))
+	seen := make(map[string]string, len(items))
+
+	for _, item := range items {
+		if item.Local == "" || item.Remote == "" {
+			return nil, fmt.Errorf("清单记录缺少本地或远程路径: %q -> %q", item.Local, item.Remote)
+		}
+
+		localPath := filepath.FromSlash(item.Local)
+		if !filepath.IsAbs(localPath) {
+			localPath = filepath.Join(baseDir, localPath)
+		}
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return nil, fmt.Errorf("清单中的文件不存在: %v", err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("清单中的路径是目录: %s", localPath)
+		}
+
+		// 远程路径以/结尾时保留原文件名
+		remoteArg, err := config.Template.expand(item.Remote)
+		if err != nil {
+			return nil, err
+		}
+		remote, err := parseRemotePath(remoteArg)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(remote, "/") {
+			remote += config.Paths.mapRelative(filepath.Base(localPath))
+		}
+		if remote, err = joinObjectKey(config.RemoteObject, remote); err != nil {
+			return nil, err
+		}
+		if remote, err = config.Template.expandFile(remote, localPath); err != nil {
+			return nil, err
+		}
+
+		if previous, ok := seen[remote]; ok {
+			return nil, fmt.Errorf("清单中 %s 和 %s 上传到同一个对象 %s", previous, item.Local, remote)
+		}
+		seen[remote] = item.Local
+
+		entries = append(entries, uploadEntry{
+			Name:      remote,
+			LocalPath: localPath,
+			Remote:    remote,
+			Headers:   item.Headers,
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLineManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []manifestItem
+		wantErr string
+	}{
+		{
+			name:    "plain",
+			content: "# 构建产物\ndist/app.js -> static/app.js\n\n  dist/index.html->index.html  \n",
+			want: []manifestItem{
+				{Local: "dist/app.js", Remote: "static/app.js"},
+				{Local: "dist/index.html", Remote: "index.html"},
+			},
+		},
+		{
+			name:    "headers",
+			content: "dist/app.js -> static/app.js | Cache-Control: max-age=31536000 | Content-Type:text/javascript\n",
+			want: []manifestItem{{Local: "dist/app.js", Remote: "static/app.js", Headers: map[string]string{
+				"Cache-Control": "max-age=31536000",
+				"Content-Type":  "text/javascript",
+			}}},
+		},
+		{name: "empty", content: "# 只有注释\n\n"},
+		{name: "missing arrow", content: "dist/a.js -> a.js\ndist/b.js b.js\n", wantErr: "第2行"},
+		{name: "invalid header", content: "dist/a.js -> a.js | Cache Control: no-cache\n", wantErr: "第1行"},
+		{name: "header without colon", content: "dist/a.js -> a.js | no-cache\n", wantErr: "无效的请求头"},
+	}
+	for _, tt := range tests {
+		got, err := parseLineManifest([]byte(tt.content))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseLineManifest() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseCSVManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []manifestItem
+		wantErr string
+	}{
+		{
+			name:    "no header",
+			content: "dist/app.js,static/app.js\ndist/a.css, static/a.css, Cache-Control: no-cache\n",
+			want: []manifestItem{
+				{Local: "dist/app.js", Remote: "static/app.js"},
+				{Local: "dist/a.css", Remote: "static/a.css", Headers: map[string]string{"Cache-Control": "no-cache"}},
+			},
+		},
+		{
+			name:    "header row",
+			content: "local,remote,Cache-Control,Content-Type\n# 注释\ndist/app.js,static/app.js,max-age=60,\n\"dist/a,b.css\",static/ab.css,,text/css\n",
+			want: []manifestItem{
+				{Local: "dist/app.js", Remote: "static/app.js", Headers: map[string]string{"Cache-Control": "max-age=60"}},
+				{Local: "dist/a,b.css", Remote: "static/ab.css", Headers: map[string]string{"Content-Type": "text/css"}},
+			},
+		},
+		{
+			// 只有第一行可以是表头
+			name:    "local as file name",
+			content: "dist/a.js,a.js\nlocal,remote\n",
+			want: []manifestItem{
+				{Local: "dist/a.js", Remote: "a.js"},
+				{Local: "local", Remote: "remote"},
+			},
+		},
+		{name: "one column", content: "dist/a.js,a.js\ndist/b.js\n", wantErr: "第2行"},
+		{name: "invalid header", content: "dist/a.js,a.js,no-cache\n", wantErr: "第1行"},
+		{name: "bad quote", content: "\"dist/a.js,a.js\n", wantErr: "解析CSV清单失败"},
+	}
+	for _, tt := range tests {
+		got, err := parseCSVManifest([]byte(tt.content))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseCSVManifest() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	want := []manifestItem{{Local: "dist/app.js", Remote: "static/app.js", Headers: map[string]string{"Cache-Control": "no-cache"}}}
+	tests := []struct {
+		file    string
+		content string
+		want    []manifestItem
+		wantErr bool
+	}{
+		{"upload.json", `[{"local": "dist/app.js", "remote": "static/app.js", "headers": {"Cache-Control": "no-cache"}}]`, want, false},
+		{"upload.JSON", `[{"local": "dist/app.js", "remote": "static/app.js", "headers": {"Cache-Control": "no-cache"}}]`, want, false},
+		{"upload.csv", "dist/app.js,static/app.js,Cache-Control: no-cache\n", want, false},
+		{"upload.txt", "dist/app.js -> static/app.js | Cache-Control: no-cache\n", want, false},
+		{"upload", "dist/app.js -> static/app.js | Cache-Control: no-cache\n", want, false},
+		{"bad.json", `{"local": "dist/app.js"}`, nil, true},
+		{"empty.json", `[]`, nil, true},
+		{"empty.txt", "# 空清单\n", nil, true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.file)
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := loadManifest(path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("loadManifest(%q) error = %v, wantErr %v", tt.file, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("loadManifest(%q) = %+v, want %+v", tt.file, got, tt.want)
+		}
+	}
+
+	if _, err := loadManifest(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Errorf("loadManifest() of a missing file: want error")
+	}
+}
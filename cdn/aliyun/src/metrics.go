@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 单文件吞吐量直方图的桶 (字节/秒)
+var throughputBuckets = []float64{
+	64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20, 64 << 20, 256 << 20,
+}
+
+// 上传指标，以Prometheus文本格式输出
+// 可在运行期间通过 /metrics 抓取，也可在退出时写入node_exporter的textfile目录
+type uploadMetrics struct {
+	mutex         sync.Mutex
+	start         time.Time
+	end           time.Time
+	uploadedBytes int64
+	dedupBytes    int64
+	files         map[string]int64 // 结果 -> 文件数
+	retries       int64
+	buckets       []int64 // 与throughputBuckets对应的累计计数
+	throughputSum float64
+	throughputN   int64
+	success       bool
+	server        *http.Server
+}
+
+func newUploadMetrics() *uploadMetrics {
+	return &uploadMetrics{
+		start:   time.Now(),
+		files:   map[string]int64{},
+		buckets: make([]int64, len(throughputBuckets)),
+	}
+}
+
+// 记录一个上传成功的文件
+func (m *uploadMetrics) observeUpload(size int64, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.uploadedBytes += size
+	m.files["uploaded"]++
+	if duration <= 0 {
+		return
+	}
+	throughput := float64(size) / duration.Seconds()
+	for i, bound := range throughputBuckets {
+		if throughput <= bound {
+			m.buckets[i]++
+		}
+	}
+	m.throughputSum += throughput
+	m.throughputN++
+}
+
+func (m *uploadMetrics) observeDedup(size int64) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.dedupBytes += size
+	m.files["deduped"]++
+}
+
+// 记录其他结果的文件: failed, skipped, interrupted
+func (m *uploadMetrics) observeFile(result string) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.files[result]++
+}
+
+func (m *uploadMetrics) observeRetry() {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.retries++
+}
+
+// 生成Prometheus文本格式
+func (m *uploadMetrics) render() []byte {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	end := m.end
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	var buf bytes.Buffer
+	writeMetric := func(name, kind, help string) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	writeMetric("oss_ultra_fast_uploaded_bytes_total", "counter", "Bytes uploaded to OSS.")
+	fmt.Fprintf(&buf, "oss_ultra_fast_uploaded_bytes_total %d\n", m.uploadedBytes)
+
+	writeMetric("oss_ultra_fast_dedup_bytes_total", "counter", "Bytes copied server-side instead of uploaded.")
+	fmt.Fprintf(&buf, "oss_ultra_fast_dedup_bytes_total %d\n", m.dedupBytes)
+
+	writeMetric("oss_ultra_fast_files_total", "counter", "Files processed, by result.")
+	results := []string{"uploaded", "deduped", "skipped", "failed", "interrupted"}
+	for result := range m.files {
+		if !containsString(results, result) {
+			results = append(results, result)
+		}
+	}
+	sort.Strings(results)
+	for _, result := range results {
+		fmt.Fprintf(&buf, "oss_ultra_fast_files_total{result=%q} %d\n", result, m.files[result])
+	}
+
+	writeMetric("oss_ultra_fast_retries_total", "counter", "Retried requests, including multipart uploads resumed after a failed part.")
+	fmt.Fprintf(&buf, "oss_ultra_fast_retries_total %d\n", m.retries)
+
+	writeMetric("oss_ultra_fast_file_throughput_bytes_per_second", "histogram", "Per-file upload throughput.")
+	for i, bound := range throughputBuckets {
+		fmt.Fprintf(&buf, "oss_ultra_fast_file_throughput_bytes_per_second_bucket{le=%q} %d\n",
+			strconv.FormatFloat(bound, 'f', -1, 64), m.buckets[i])
+	}
+	fmt.Fprintf(&buf, "oss_ultra_fast_file_throughput_bytes_per_second_bucket{le=\"+Inf\"} %d\n", m.throughputN)
+	fmt.Fprintf(&buf, "oss_ultra_fast_file_throughput_bytes_per_second_sum %g\n", m.throughputSum)
+	fmt.Fprintf(&buf, "oss_ultra_fast_file_throughput_bytes_per_second_count %d\n", m.throughputN)
+
+	writeMetric("oss_ultra_fast_run_duration_seconds", "gauge", "Duration of the current or last run.")
+	fmt.Fprintf(&buf, "oss_ultra_fast_run_duration_seconds %g\n", end.Sub(m.start).Seconds())
+
+	writeMetric("oss_ultra_fast_run_start_timestamp_seconds", "gauge", "Unix time the run started.")
+	fmt.Fprintf(&buf, "oss_ultra_fast_run_start_timestamp_seconds %d\n", m.start.Unix())
+
+	if !m.end.IsZero() {
+		writeMetric("oss_ultra_fast_last_run_success", "gauge", "Whether the last run finished without errors.")
+		success := 0
+		if m.success {
+			success = 1
+		}
+		fmt.Fprintf(&buf, "oss_ultra_fast_last_run_success %d\n", success)
+	}
+	return buf.Bytes()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// 在本地地址上提供 /metrics
+func (m *uploadMetrics) serve(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("监听指标地址失败: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(m.render())
+	})
+	m.server = &http.Server{Handler: mux}
+	go m.server.Serve(listener)
+
+	fmt.Printf("📊 指标地址: http://%s/metrics\n", listener.Addr())
+	return nil
+}
+
+// 结束统计，关闭 /metrics 并写入textfile
+func (m *uploadMetrics) finish(success bool, textfile string) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	m.end = time.Now()
+	m.success = success
+	m.mutex.Unlock()
+
+	if m.server != nil {
+		m.server.Close()
+	}
+	if textfile == "" {
+		return
+	}
+
+	// 先写临时文件再改名，避免node_exporter读到写了一半的文件
+	tmpFile := filepath.Join(filepath.Dir(textfile), "."+filepath.Base(textfile)+".tmp")
+	if err := os.WriteFile(tmpFile, m.render(), 0644); err != nil {
+		fmt.Printf("⚠️  写入指标文件失败: %v\n", err)
+		return
+	}
+	if err := os.Rename(tmpFile, textfile); err != nil {
+		os.Remove(tmpFile)
+		fmt.Printf("⚠️  写入指标文件失败: %v\n", err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 指标文本中某一行的值
+func metricValue(t *testing.T, text, series string) string {
+	t.Helper()
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, series+" ") {
+			return strings.TrimPrefix(line, series+" ")
+		}
+	}
+	t.Errorf("metric %s not found", series)
+	return ""
+}
+
+func TestUploadMetricsRender(t *testing.T) {
+	m := newUploadMetrics()
+	m.observeUpload(100<<10, time.Second) // 100KB/s
+	m.observeUpload(2<<20, time.Second)   // 2MB/s
+	m.observeUpload(512<<20, time.Second) // 超过最大的桶
+	m.observeUpload(10, 0)                // 没有耗时，不计入直方图
+	m.observeDedup(1 << 20)
+	m.observeFile("failed")
+	m.observeFile("retried-later")
+	m.observeRetry()
+	m.observeRetry()
+
+	text := string(m.render())
+	tests := []struct {
+		series string
+		want   string
+	}{
+		{"oss_ultra_fast_uploaded_bytes_total", "539070474"},
+		{"oss_ultra_fast_dedup_bytes_total", "1048576"},
+		{`oss_ultra_fast_files_total{result="uploaded"}`, "4"},
+		{`oss_ultra_fast_files_total{result="deduped"}`, "1"},
+		{`oss_ultra_fast_files_total{result="failed"}`, "1"},
+		{`oss_ultra_fast_files_total{result="skipped"}`, "0"},
+		{`oss_ultra_fast_files_total{result="retried-later"}`, "1"},
+		{"oss_ultra_fast_retries_total", "2"},
+		// 桶是累计的: le=65536不含100KB/s，le=262144含100KB/s
+		{`oss_ultra_fast_file_throughput_bytes_per_second_bucket{le="65536"}`, "0"},
+		{`oss_ultra_fast_file_throughput_bytes_per_second_bucket{le="262144"}`, "1"},
+		{`oss_ultra_fast_file_throughput_bytes_per_second_bucket{le="1048576"}`, "1"},
+		{`oss_ultra_fast_file_throughput_bytes_per_second_bucket{le="4194304"}`, "2"},
+		{`oss_ultra_fast_file_throughput_bytes_per_second_bucket{le="268435456"}`, "2"},
+		{`oss_ultra_fast_file_throughput_bytes_per_second_bucket{le="+Inf"}`, "3"},
+		{"oss_ultra_fast_file_throughput_bytes_per_second_count", "3"},
+	}
+	for _, tt := range tests {
+		if got := metricValue(t, text, tt.series); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.series, got, tt.want)
+		}
+	}
+	if strings.Contains(text, "oss_ultra_fast_last_run_success") {
+		t.Errorf("last_run_success is reported before the run finished")
+	}
+	if !strings.Contains(text, "# TYPE oss_ultra_fast_file_throughput_bytes_per_second histogram\n") {
+		t.Errorf("histogram TYPE line missing")
+	}
+}
+
+func TestUploadMetricsFinish(t *testing.T) {
+	tests := []struct {
+		success bool
+		want    string
+	}{
+		{true, "1"},
+		{false, "0"},
+	}
+	for _, tt := range tests {
+		textfile := filepath.Join(t.TempDir(), "oss_upload.prom")
+		m := newUploadMetrics()
+		m.finish(tt.success, textfile)
+
+		data, err := os.ReadFile(textfile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := metricValue(t, string(data), "oss_ultra_fast_last_run_success"); got != tt.want {
+			t.Errorf("finish(%v): last_run_success = %s, want %s", tt.success, got, tt.want)
+		}
+		// 不留下临时文件
+		if entries, _ := os.ReadDir(filepath.Dir(textfile)); len(entries) != 1 {
+			t.Errorf("finish(%v) left %d files in the textfile directory", tt.success, len(entries))
+		}
+	}
+
+	var disabled *uploadMetrics
+	disabled.observeUpload(1, time.Second)
+	disabled.observeFile("failed")
+	disabled.finish(true, "")
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+const defaultAbandonedAge = 24 * time.Hour
+
+// 未完成的分片上传
+type pendingMultipart struct {
+	Upload oss.UncompletedUpload
+	Parts  int
+	Size   int64
+}
+
+func showMultipartUsage() {
+	fmt.Printf(`未完成分片上传管理 - 清理中断上传留下的碎片
+
+用法: %s mpu [前缀] [选项]
+
+选项:
+  --older-than AGE  只处理发起时间早于AGE的上传，如12h、7d，默认24h
+  --abort           中止符合条件的上传并释放已上传分片
+  -f                中止前不再确认
+  -h                帮助
+
+示例:
+  %s mpu releases/                       列出前缀下所有未完成的上传
+  %s mpu --abort                         中止24小时前发起的上传
+  %s mpu releases/ --older-than 2h --abort -f
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
+
+func runMultipart(args []string) error {
+	prefix := ""
+	olderThan := defaultAbandonedAge
+	abort := false
+	force := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--older-than":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--older-than 需要参数")
+			}
+			age, err := parseTTL(args[i+1])
+			if err != nil {
+				return err
+			}
+			olderThan = age
+			i++
+		case "--abort":
+			abort = true
+		case "-f", "--force":
+			force = true
+		case "-h", "--help":
+			showMultipartUsage()
+			os.Exit(0)
+		default:
+			if strings.HasPrefix(args[i], "-") {
+				return fmt.Errorf("未知参数: %s", args[i])
+			}
+			prefix = args[i]
+		}
+	}
+
+	config, client, err := loadObjectClient()
+	if err != nil {
+		return err
+	}
+	bucketName, prefix := parseOSSPath(prefix, config.BucketName)
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return fmt.Errorf("获取bucket失败: %v", err)
+	}
+
+	uploads, err := listPendingMultiparts(bucket, prefix)
+	if err != nil {
+		return err
+	}
+	if len(uploads) == 0 {
+		fmt.Printf("✅ oss://%s/%s 下没有未完成的分片上传\n", bucketName, prefix)
+		return nil
+	}
+
+	now := time.Now()
+	var expired []pendingMultipart
+	var totalSize, expiredSize int64
+	fmt.Printf("发起时间               时长     分片        已上传  对象 (UploadId)\n")
+	for _, upload := range uploads {
+		age := now.Sub(upload.Upload.Initiated)
+		mark := " "
+		if age >= olderThan {
+			mark = "*"
+			expired = append(expired, upload)
+			expiredSize += upload.Size
+		}
+		totalSize += upload.Size
+		fmt.Printf("%-19s  %8s %s%5d  %12s  %s (%s)\n", upload.Upload.Initiated.Local().Format("2006-01-02 15:04:05"),
+			formatAge(age), mark, upload.Parts, humanSize(upload.Size), upload.Upload.Key, upload.Upload.UploadID)
+	}
+
+	fmt.Printf("\n未完成上传: %d 个, 占用 %s\n", len(uploads), humanSize(totalSize))
+	fmt.Printf("超过 %s (*): %d 个, 占用 %s\n", formatAge(olderThan), len(expired), humanSize(expiredSize))
+
+	if !abort || len(expired) == 0 {
+		if len(expired) > 0 {
+			fmt.Printf("💡 使用 --abort 中止超时的上传\n")
+		}
+		return nil
+	}
+
+	if !force && !confirmAction(fmt.Sprintf("确认中止 %d 个分片上传?", len(expired))) {
+		fmt.Printf("❌ 用户取消操作\n")
+		return nil
+	}
+
+	failed := 0
+	for _, upload := range expired {
+		imur := oss.InitiateMultipartUploadResult{Bucket: bucketName, Key: upload.Upload.Key, UploadID: upload.Upload.UploadID}
+		if err := bucket.AbortMultipartUpload(imur); err != nil {
+			fmt.Printf("❌ 中止失败 %s: %v\n", upload.Upload.Key, err)
+			failed++
+			continue
+		}
+		fmt.Printf("🗑️  已中止 %s (%s)\n", upload.Upload.Key, upload.Upload.UploadID)
+	}
+
+	fmt.Printf("\n🎯 已中止 %d/%d 个上传\n", len(expired)-failed, len(expired))
+	if failed > 0 {
+		return fmt.Errorf("%d 个上传中止失败", failed)
+	}
+	return nil
+}
+
+// 列出前缀下所有未完成的分片上传及其已上传分片
+func listPendingMultiparts(bucket *oss.Bucket, prefix string) ([]pendingMultipart, error) {
+	var pending []pendingMultipart
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		options := []oss.Option{oss.Prefix(prefix), oss.MaxUploads(listPageSize)}
+		if keyMarker != "" {
+			options = append(options, oss.KeyMarker(keyMarker), oss.UploadIDMarker(uploadIDMarker))
+		}
+		result, err := bucket.ListMultipartUploads(options...)
+		if err != nil {
+			return nil, fmt.Errorf("列举分片上传失败: %v", err)
+		}
+
+		for _, upload := range result.Uploads {
+			item := pendingMultipart{Upload: upload}
+			imur := oss.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: upload.Key, UploadID: upload.UploadID}
+			partMarker := 0
+			for {
+				parts, err := bucket.ListUploadedParts(imur, oss.PartNumberMarker(partMarker))
+				if err != nil {
+					// 列举期间上传可能已完成或被中止
+					break
+				}
+				for _, part := range parts.UploadedParts {
+					item.Parts++
+					item.Size += int64(part.Size)
+				}
+				if !parts.IsTruncated {
+					break
+				}
+				fmt.Sscanf(parts.NextPartNumberMarker, "%d", &partMarker)
+			}
+			pending = append(pending, item)
+		}
+
+		if !result.IsTruncated {
+			return pending, nil
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+}
+
+func formatAge(age time.Duration) string {
+	switch {
+	case age >= 24*time.Hour:
+		return fmt.Sprintf("%.1f天", age.Hours()/24)
+	case age >= time.Hour:
+		return fmt.Sprintf("%.1f小时", age.Hours())
+	default:
+		return fmt.Sprintf("%.0f分钟", age.Minutes())
+	}
+}
+
+// 本次运行发起的分片上传，从InitiateMultipartUpload的响应中记录
+// 出错时只中止这些上传，不影响其他进程同时上传同一对象
+type multipartTracker struct {
+	mutex   sync.Mutex
+	uploads map[string][]string // 对象 -> UploadID
+}
+
+func newMultipartTracker() *multipartTracker {
+	return &multipartTracker{uploads: map[string][]string{}}
+}
+
+func (t *multipartTracker) record(key, uploadID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.uploads[key] = append(t.uploads[key], uploadID)
+}
+
+// 取出对象的UploadID并清除记录
+func (t *multipartTracker) take(key string) []string {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	uploadIDs := t.uploads[key]
+	delete(t.uploads, key)
+	return uploadIDs
+}
+
+// 读取InitiateMultipartUpload的响应，记录UploadID后原样交给SDK
+type multipartTrackingTransport struct {
+	base    http.RoundTripper
+	tracker *multipartTracker
+}
+
+func (t *multipartTrackingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || req.Method != "POST" || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	if _, initiate := req.URL.Query()["uploads"]; !initiate {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var result oss.InitiateMultipartUploadResult
+	if xml.Unmarshal(body, &result) == nil && result.UploadID != "" {
+		t.tracker.record(result.Key, result.UploadID)
+	}
+	return resp, nil
+}
+
+// 分片checkpoint中记录的UploadID，SDK在第一个分片完成后才写入
+func checkpointUploadID(cpFile string) string {
+	content, err := os.ReadFile(cpFile)
+	if err != nil {
+		return ""
+	}
+	var checkpoint struct {
+		UploadID string
+	}
+	if json.Unmarshal(content, &checkpoint) != nil {
+		return ""
+	}
+	return checkpoint.UploadID
+}
+
+// 上传出错后，中止本次运行发起的 (以及checkpoint中续传的) 分片上传
+// 出错时网络往往也不稳定，中止会重试几次
+func abortOwnMultiparts(bucket *oss.Bucket, key string, uploadIDs []string) {
+	const attempts = 3
+
+	if len(uploadIDs) == 0 {
+		return
+	}
+	seen := map[string]bool{}
+	for _, uploadID := range uploadIDs {
+		if uploadID == "" || seen[uploadID] {
+			continue
+		}
+		seen[uploadID] = true
+
+		var err error
+		imur := oss.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: key, UploadID: uploadID}
+		for attempt := 1; attempt <= attempts; attempt++ {
+			if err = bucket.AbortMultipartUpload(imur); err == nil {
+				break
+			}
+			// 已经完成或已被中止
+			var serviceErr oss.ServiceError
+			if errors.As(err, &serviceErr) && serviceErr.Code == "NoSuchUpload" {
+				err = nil
+				break
+			}
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		if err != nil {
+			fmt.Printf("⚠️  中止分片上传失败 %s: %v\n", uploadID, err)
+			fmt.Printf("💡 稍后可使用 mpu %s --abort 清理\n", key)
+			continue
+		}
+		fmt.Printf("🗑️  已中止未完成的分片上传 %s\n", uploadID)
+	}
+}
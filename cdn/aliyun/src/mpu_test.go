@@ -0,0 +1,126 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{30 * time.Second, "0分钟"},
+		{45 * time.Minute, "45分钟"},
+		{90 * time.Minute, "1.5小时"},
+		{36 * time.Hour, "1.5天"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.age); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestMultipartTracker(t *testing.T) {
+	tracker := newMultipartTracker()
+	tracker.record("a.bin", "id1")
+	tracker.record("a.bin", "id2")
+	tracker.record("b.bin", "id3")
+
+	if got, want := tracker.take("a.bin"), []string{"id1", "id2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("take(a.bin) = %q, want %q", got, want)
+	}
+	if got := tracker.take("a.bin"); got != nil {
+		t.Errorf("second take(a.bin) = %q, want nil", got)
+	}
+	if got, want := tracker.take("b.bin"), []string{"id3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("take(b.bin) = %q, want %q", got, want)
+	}
+
+	var disabled *multipartTracker
+	if got := disabled.take("a.bin"); got != nil {
+		t.Errorf("nil tracker take() = %q, want nil", got)
+	}
+}
+
+func TestMultipartTrackingTransport(t *testing.T) {
+	const initiateResult = `<?xml version="1.0" encoding="UTF-8"?>
+<InitiateMultipartUploadResult>
+  <Bucket>bkt</Bucket>
+  <Key>big.bin</Key>
+  <UploadId>0004B9895DBBB6EC98E36</UploadId>
+</InitiateMultipartUploadResult>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte(initiateResult))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		want   []string
+	}{
+		{"initiate", "POST", "?uploads", []string{"0004B9895DBBB6EC98E36"}},
+		{"complete", "POST", "?uploadId=1", nil},
+		{"get", "GET", "?uploads", nil},
+		{"failed initiate", "POST", "?uploads&fail=1", nil},
+	}
+	for _, tt := range tests {
+		tracker := newMultipartTracker()
+		client := &http.Client{Transport: &multipartTrackingTransport{base: http.DefaultTransport, tracker: tracker}}
+		req, err := http.NewRequest(tt.method, server.URL+"/big.bin"+tt.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		// SDK仍能读到完整的响应
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != initiateResult {
+			t.Errorf("%s: response body was not restored", tt.name)
+		}
+		if got := tracker.take("big.bin"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: recorded %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckpointUploadID(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"checkpoint", `{"Magic":"FE8BB4EA-B593-4FAC-AD7A-2459A36E2E62","FilePath":"big.bin","UploadID":"0004B999EF5A239BB9138C6227D69F95"}`, "0004B999EF5A239BB9138C6227D69F95"},
+		{"before first part", `{"Magic":"FE8BB4EA-B593-4FAC-AD7A-2459A36E2E62","UploadID":""}`, ""},
+		{"corrupted", `{"UploadID":`, ""},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "big.bin.cp")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := checkpointUploadID(path); got != tt.want {
+			t.Errorf("%s: checkpointUploadID() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := checkpointUploadID(filepath.Join(t.TempDir(), "missing.cp")); got != "" {
+		t.Errorf("checkpointUploadID() of a missing file = %q, want empty", got)
+	}
+}
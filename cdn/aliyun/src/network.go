@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+const (
+	defaultConnectTimeout = 30 * time.Second
+	defaultReadTimeout    = 60 * time.Second
+	defaultRetries        = 3
+	retryBaseDelay        = time.Second
+	retryMaxDelay         = 30 * time.Second
+)
+
+// 网络相关配置
+type NetworkConfig struct {
+	ConnectTimeout time.Duration // 建立连接超时
+	ReadTimeout    time.Duration // 读写超时，连接上超过该时间没有数据即失败
+	Retries        int           // 每个请求失败后的重试次数
+	Proxy          string        // 代理地址: http://, https://, socks5://
+	UseCname       bool          // Endpoint为绑定到bucket的自定义域名
+	UseHTTPS       bool          // Endpoint未指定协议时使用HTTPS
+}
+
+func defaultNetworkConfig() NetworkConfig {
+	return NetworkConfig{
+		ConnectTimeout: defaultConnectTimeout,
+		ReadTimeout:    defaultReadTimeout,
+		Retries:        defaultRetries,
+	}
+}
+
+// 校验代理地址，SOCKS代理使用socks5://
+func parseProxyURL(value string) (*url.URL, error) {
+	proxyURL, err := url.Parse(value)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("无效的代理地址: %s", value)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return proxyURL, nil
+	default:
+		return nil, fmt.Errorf("不支持的代理协议: %s (支持 http, https, socks5)", proxyURL.Scheme)
+	}
+}
+
+// 补全Endpoint协议，已指定协议时保持不变
+func endpointURL(config *UltraConfig) string {
+	if strings.Contains(config.Endpoint, "://") {
+		return config.Endpoint
+	}
+	if config.Network.UseHTTPS {
+		return "https://" + config.Endpoint
+	}
+	return "http://" + config.Endpoint
+}
+
+// 对象的访问地址，兼容自定义域名和IP形式的Endpoint
+func objectURL(config *UltraConfig, key string) string {
+	endpoint, err := url.Parse(endpointURL(config))
+	if err != nil {
+		return fmt.Sprintf("https://%s.%s/%s", config.BucketName, config.Endpoint, key)
+	}
+
+	// 未指定协议时按公网访问习惯展示为HTTPS
+	scheme := "https"
+	if strings.Contains(config.Endpoint, "://") {
+		scheme = endpoint.Scheme
+	}
+	switch {
+	case config.Network.UseCname:
+		return fmt.Sprintf("%s://%s/%s", scheme, endpoint.Host, key)
+	case net.ParseIP(endpoint.Hostname()) != nil:
+		return fmt.Sprintf("%s://%s/%s/%s", scheme, endpoint.Host, config.BucketName, key)
+	default:
+		return fmt.Sprintf("%s://%s.%s/%s", scheme, config.BucketName, endpoint.Host, key)
+	}
+}
+
+// 根据网络配置创建HTTP客户端，上传期间附加中断处理
+func newUltraHTTPClient(config *UltraConfig) (*http.Client, error) {
+	network := config.Network
+	dialer := &net.Dialer{
+		Timeout:   network.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, netw, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, netw, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &timeoutConn{Conn: conn, timeout: network.ReadTimeout}, nil
+		},
+		TLSHandshakeTimeout:   network.ConnectTimeout,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   100,
+		IdleConnTimeout:       50 * time.Second,
+		ResponseHeaderTimeout: network.ReadTimeout,
+	}
+	if network.Proxy != "" {
+		proxyURL, err := parseProxyURL(network.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if config.Interrupt != nil {
+		roundTripper = &interruptTransport{base: transport, ctx: config.Interrupt.ctx}
+	}
+	if config.Multiparts != nil {
+		roundTripper = &multipartTrackingTransport{base: roundTripper, tracker: config.Multiparts}
+	}
+	return &http.Client{Transport: roundTripper}, nil
+}
+
+// 每次读写前刷新超时，长时间没有数据的连接会失败
+type timeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	if c.timeout > 0 {
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *timeoutConn) Write(b []byte) (int, error) {
+	if c.timeout > 0 {
+		c.Conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	return c.Conn.Write(b)
+}
+
+// 判断错误是否值得重试，返回简短的原因
+func retryableCause(err error) (string, bool) {
+	if errors.Is(err, context.Canceled) {
+		return "", false
+	}
+
+	var serviceErr oss.ServiceError
+	if errors.As(err, &serviceErr) {
+		if serviceErr.StatusCode >= 500 || serviceErr.StatusCode == http.StatusTooManyRequests ||
+			serviceErr.Code == "RequestTimeout" {
+			return fmt.Sprintf("HTTP %d %s", serviceErr.StatusCode, serviceErr.Code), true
+		}
+		return "", false
+	}
+
+	var crcErr oss.CRCCheckError
+	if errors.As(err, &crcErr) {
+		return "CRC校验失败", true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "超时", true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return "连接被断开", true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return fmt.Sprintf("网络错误: %v", opErr.Err), true
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Sprintf("网络错误: %v", urlErr.Err), true
+	}
+
+	// SDK部分网络错误未保留原始类型
+	message := err.Error()
+	for _, hint := range []string{"connection reset", "broken pipe", "EOF", "timeout", "connection refused"} {
+		if strings.Contains(message, hint) {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+var (
+	jitterMutex sync.Mutex
+	jitterRand  = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// 第attempt次重试前的等待时间: 指数退避，在[delay/2, delay]内随机抖动
+// 避免大量并发分片同时重试
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay << uint(attempt-1)
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	jitterMutex.Lock()
+	defer jitterMutex.Unlock()
+	return delay/2 + time.Duration(jitterRand.Int63n(int64(delay/2)+1))
+}
+
+// 执行请求，可重试的错误按退避策略重试，每次重试打印原因
+func withRetry(config *UltraConfig, what string, fn func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt >= config.Network.Retries || config.Interrupt.Stopped() {
+			return err
+		}
+		cause, ok := retryableCause(err)
+		if !ok {
+			return err
+		}
+
+		config.Metrics.observeRetry()
+		delay := retryDelay(attempt + 1)
+		fmt.Printf("\n🔁 %s 失败 (%s)，%.1f秒后第%d/%d次重试\n",
+			what, cause, delay.Seconds(), attempt+1, config.Network.Retries)
+		if !config.Interrupt.Sleep(delay) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,551 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+const (
+	deleteBatchSize        = 1000               // DeleteObjects单次最多1000个
+	copyMultipartThreshold = 1024 * 1024 * 1024 // 超过1GB使用UploadPartCopy
+	copyPartSize           = 100 * 1024 * 1024  // 分片拷贝大小
+	copyRoutines           = 10                 // 分片拷贝并发数
+	confirmSampleSize      = 10                 // 确认提示中展示的对象数
+	listPageSize           = 1000
+)
+
+// 对象管理命令的公共参数
+type objectCommand struct {
+	Args      []string // 位置参数
+	Recursive bool     // -r 递归/按前缀操作
+	RawPrefix bool     // --prefix 按原样匹配前缀，不补全结尾的/
+	Force     bool     // -f 跳过确认
+	Human     bool     // -H 人类可读大小
+	Delimiter string   // ls的分隔符
+	Limit     int      // ls最多输出数量
+}
+
+func showObjectUsage() {
+	fmt.Printf(`OSS对象管理命令
+
+用法:
+  %s ls [前缀] [-r] [-H] [--delimiter D] [--limit N]
+  %s stat <对象>
+  %s rm <对象> | rm <前缀> -r [-f]
+  %s cp <源> <目标> [-r]
+  %s mv <源> <目标> [-r] [-f]
+
+路径格式:
+  releases/v1.0/app.apk          默认bucket中的对象
+  oss://other-bucket/path/file   指定bucket中的对象
+
+选项:
+  -r             递归: ls列出全部层级，rm/cp/mv按目录批量操作
+                 (releases/v1 按 releases/v1/ 匹配，不包括 releases/v10/)
+  --prefix       rm/cp/mv -r 时按原样匹配前缀 (releases/v1 也匹配 releases/v10/)
+  -f             跳过确认提示
+  -H             以KB/MB/GB显示大小
+  --delimiter D  ls分组分隔符，默认/
+  --limit N      ls最多显示N条
+
+示例:
+  %s ls releases/ -H
+  %s rm releases/v0.9/ -r
+  %s cp releases/v1.0/ oss://backup-bucket/releases/v1.0/ -r
+  %s mv tmp/app.apk releases/v1.0/app.apk
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
+
+func parseObjectCommand(args []string) (*objectCommand, error) {
+	cmd := &objectCommand{Delimiter: "/"}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-r", "--recursive":
+			cmd.Recursive = true
+		case "-f", "--force":
+			cmd.Force = true
+		case "-H", "--human":
+			cmd.Human = true
+		case "--prefix":
+			cmd.RawPrefix = true
+		case "--delimiter", "--limit":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s 需要参数", args[i])
+			}
+			if args[i] == "--delimiter" {
+				cmd.Delimiter = args[i+1]
+			} else {
+				limit, err := strconv.Atoi(args[i+1])
+				if err != nil || limit <= 0 {
+					return nil, fmt.Errorf("无效的数量: %s", args[i+1])
+				}
+				cmd.Limit = limit
+			}
+			i++
+		case "-h", "--help":
+			showObjectUsage()
+			os.Exit(0)
+		default:
+			if strings.HasPrefix(args[i], "-") {
+				return nil, fmt.Errorf("未知参数: %s", args[i])
+			}
+			cmd.Args = append(cmd.Args, args[i])
+		}
+	}
+	return cmd, nil
+}
+
+// -r 操作的前缀: 非空且不以/结尾时补上/，避免 releases/v1 匹配到 releases/v10/
+// 指定 --prefix 时按原样使用
+func recursivePrefix(key string, raw bool) string {
+	if raw || key == "" || strings.HasSuffix(key, "/") {
+		return key
+	}
+	return key + "/"
+}
+
+// 解析 oss://bucket/key 或 key 格式的路径
+func parseOSSPath(path, defaultBucket string) (string, string) {
+	if strings.HasPrefix(path, "oss://") {
+		parts := strings.SplitN(strings.TrimPrefix(path, "oss://"), "/", 2)
+		if len(parts) == 1 {
+			return parts[0], ""
+		}
+		return parts[0], parts[1]
+	}
+	return defaultBucket, normalizeRemotePath(path)
+}
+
+func humanSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.2f %s", value, units[unit])
+}
+
+func formatSize(size int64, human bool) string {
+	if human {
+		return humanSize(size)
+	}
+	return strconv.FormatInt(size, 10)
+}
+
+// 加载配置并返回客户端
+func loadObjectClient() (*UltraConfig, *oss.Client, error) {
+	config := &UltraConfig{Network: defaultNetworkConfig()}
+	if err := loadUltraOSSConfig(config); err != nil {
+		return nil, nil, err
+	}
+	client, err := newUltraClient(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, client, nil
+}
+
+// 列出前缀下的全部对象
+func listAllObjects(bucket *oss.Bucket, prefix string) ([]oss.ObjectProperties, error) {
+	var objects []oss.ObjectProperties
+	token := ""
+	for {
+		options := []oss.Option{oss.Prefix(prefix), oss.MaxKeys(listPageSize)}
+		if token != "" {
+			options = append(options, oss.ContinuationToken(token))
+		}
+		result, err := bucket.ListObjectsV2(options...)
+		if err != nil {
+			return nil, fmt.Errorf("列举对象失败: %v", err)
+		}
+		objects = append(objects, result.Objects...)
+		if !result.IsTruncated {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// 交互确认，返回用户是否同意
+func confirmAction(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	var answer string
+	fmt.Scanln(&answer)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func printObjectSample(keys []string) {
+	for i, key := range keys {
+		if i >= confirmSampleSize {
+			fmt.Printf("  ... 以及另外 %d 个对象\n", len(keys)-confirmSampleSize)
+			break
+		}
+		fmt.Printf("  %s\n", key)
+	}
+}
+
+func runList(args []string) error {
+	cmd, err := parseObjectCommand(args)
+	if err != nil {
+		return err
+	}
+	config, client, err := loadObjectClient()
+	if err != nil {
+		return err
+	}
+
+	path := ""
+	if len(cmd.Args) > 0 {
+		path = cmd.Args[0]
+	}
+	bucketName, prefix := parseOSSPath(path, config.BucketName)
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return fmt.Errorf("获取bucket失败: %v", err)
+	}
+
+	delimiter := cmd.Delimiter
+	if cmd.Recursive {
+		delimiter = ""
+	}
+
+	var objectCount, prefixCount int
+	var totalSize int64
+	token := ""
+	for {
+		options := []oss.Option{oss.Prefix(prefix), oss.MaxKeys(listPageSize)}
+		if delimiter != "" {
+			options = append(options, oss.Delimiter(delimiter))
+		}
+		if token != "" {
+			options = append(options, oss.ContinuationToken(token))
+		}
+		result, err := bucket.ListObjectsV2(options...)
+		if err != nil {
+			return fmt.Errorf("列举对象失败: %v", err)
+		}
+
+		for _, commonPrefix := range result.CommonPrefixes {
+			if cmd.Limit > 0 && objectCount+prefixCount >= cmd.Limit {
+				break
+			}
+			fmt.Printf("%19s  %12s  oss://%s/%s\n", "", "DIR", bucketName, commonPrefix)
+			prefixCount++
+		}
+		for _, object := range result.Objects {
+			if cmd.Limit > 0 && objectCount+prefixCount >= cmd.Limit {
+				break
+			}
+			fmt.Printf("%s  %12s  oss://%s/%s\n", object.LastModified.Local().Format("2006-01-02 15:04:05"),
+				formatSize(object.Size, cmd.Human), bucketName, object.Key)
+			objectCount++
+			totalSize += object.Size
+		}
+
+		if !result.IsTruncated || (cmd.Limit > 0 && objectCount+prefixCount >= cmd.Limit) {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	fmt.Printf("\n对象: %d 个, 目录: %d 个, 总大小: %s\n", objectCount, prefixCount, humanSize(totalSize))
+	return nil
+}
+
+func runStat(args []string) error {
+	cmd, err := parseObjectCommand(args)
+	if err != nil {
+		return err
+	}
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("请指定一个对象")
+	}
+	config, client, err := loadObjectClient()
+	if err != nil {
+		return err
+	}
+
+	bucketName, key := parseOSSPath(cmd.Args[0], config.BucketName)
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return fmt.Errorf("获取bucket失败: %v", err)
+	}
+
+	header, err := bucket.GetObjectDetailedMeta(key)
+	if err != nil {
+		if serviceErr, ok := err.(oss.ServiceError); ok && serviceErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("对象不存在: oss://%s/%s", bucketName, key)
+		}
+		return fmt.Errorf("获取对象信息失败: %v", err)
+	}
+
+	fmt.Printf("对象: oss://%s/%s\n", bucketName, key)
+	if size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		fmt.Printf("大小: %s (%d 字节)\n", humanSize(size), size)
+	}
+	fmt.Println()
+
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%-32s %s\n", name+":", strings.Join(header[name], ", "))
+	}
+	return nil
+}
+
+func runRemove(args []string) error {
+	cmd, err := parseObjectCommand(args)
+	if err != nil {
+		return err
+	}
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("请指定一个对象或前缀")
+	}
+	config, client, err := loadObjectClient()
+	if err != nil {
+		return err
+	}
+
+	bucketName, key := parseOSSPath(cmd.Args[0], config.BucketName)
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return fmt.Errorf("获取bucket失败: %v", err)
+	}
+
+	if !cmd.Recursive {
+		exists, err := bucket.IsObjectExist(key)
+		if err != nil {
+			return fmt.Errorf("检查对象失败: %v", err)
+		}
+		if !exists {
+			return fmt.Errorf("对象不存在: oss://%s/%s (按前缀删除请使用 -r)", bucketName, key)
+		}
+		if err := bucket.DeleteObject(key); err != nil {
+			return fmt.Errorf("删除失败: %v", err)
+		}
+		fmt.Printf("🗑️  已删除 oss://%s/%s\n", bucketName, key)
+		return nil
+	}
+
+	if key == "" && !cmd.Force {
+		fmt.Printf("⚠️  未指定前缀，将删除整个bucket中的对象\n")
+	}
+	key = recursivePrefix(key, cmd.RawPrefix)
+	objects, err := listAllObjects(bucket, key)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		fmt.Printf("前缀下没有对象: oss://%s/%s\n", bucketName, key)
+		return nil
+	}
+
+	keys := make([]string, len(objects))
+	var totalSize int64
+	for i, object := range objects {
+		keys[i] = object.Key
+		totalSize += object.Size
+	}
+
+	fmt.Printf("将删除 oss://%s/%s 下的 %d 个对象 (%s):\n", bucketName, key, len(keys), humanSize(totalSize))
+	printObjectSample(keys)
+	if !cmd.Force && !confirmAction("确认删除?") {
+		fmt.Printf("❌ 用户取消操作\n")
+		return nil
+	}
+
+	deleted, err := deleteObjectsBatched(bucket, keys)
+	fmt.Printf("🗑️  已删除 %d/%d 个对象\n", deleted, len(keys))
+	return err
+}
+
+// 按1000个一批调用DeleteObjects，返回实际删除的数量
+// 静默模式下SDK不解析响应体，因此使用详细模式，响应中没有的key即删除失败
+func deleteObjectsBatched(bucket *oss.Bucket, keys []string) (int, error) {
+	deleted := 0
+	var failed []string
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch := keys[start:end]
+		result, err := bucket.DeleteObjects(batch)
+		if err != nil {
+			return deleted, fmt.Errorf("批量删除失败: %v", err)
+		}
+		done := make(map[string]bool, len(result.DeletedObjects))
+		for _, key := range result.DeletedObjects {
+			done[key] = true
+		}
+		for _, key := range batch {
+			if done[key] {
+				deleted++
+			} else {
+				failed = append(failed, key)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		sample := failed
+		if len(sample) > confirmSampleSize {
+			sample = sample[:confirmSampleSize]
+		}
+		message := strings.Join(sample, ", ")
+		if len(failed) > len(sample) {
+			message += " 等"
+		}
+		return deleted, fmt.Errorf("%d 个对象删除失败: %s", len(failed), message)
+	}
+	return deleted, nil
+}
+
+// 服务端拷贝任务
+type copyTask struct {
+	SrcKey string
+	DstKey string
+	Size   int64
+}
+
+func runCopy(args []string) error {
+	return copyOrMove(args, false)
+}
+
+func runMove(args []string) error {
+	return copyOrMove(args, true)
+}
+
+func copyOrMove(args []string, move bool) error {
+	cmd, err := parseObjectCommand(args)
+	if err != nil {
+		return err
+	}
+	if len(cmd.Args) != 2 {
+		return fmt.Errorf("请指定源和目标")
+	}
+	config, client, err := loadObjectClient()
+	if err != nil {
+		return err
+	}
+
+	srcBucketName, srcKey := parseOSSPath(cmd.Args[0], config.BucketName)
+	dstBucketName, dstKey := parseOSSPath(cmd.Args[1], config.BucketName)
+	srcBucket, err := client.Bucket(srcBucketName)
+	if err != nil {
+		return fmt.Errorf("获取bucket失败: %v", err)
+	}
+	dstBucket, err := client.Bucket(dstBucketName)
+	if err != nil {
+		return fmt.Errorf("获取bucket失败: %v", err)
+	}
+
+	var tasks []copyTask
+	if cmd.Recursive {
+		// 源和目标都按目录处理，--prefix 时保持原样拼接
+		srcKey = recursivePrefix(srcKey, cmd.RawPrefix)
+		dstKey = recursivePrefix(dstKey, cmd.RawPrefix)
+		objects, err := listAllObjects(srcBucket, srcKey)
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			tasks = append(tasks, copyTask{
+				SrcKey: object.Key,
+				DstKey: dstKey + strings.TrimPrefix(object.Key, srcKey),
+				Size:   object.Size,
+			})
+		}
+	} else {
+		header, err := srcBucket.GetObjectDetailedMeta(srcKey)
+		if err != nil {
+			return fmt.Errorf("源对象不存在: oss://%s/%s", srcBucketName, srcKey)
+		}
+		size, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+		// 目标以/结尾时拷贝到该目录下
+		if dstKey == "" || strings.HasSuffix(dstKey, "/") {
+			dstKey += srcKey[strings.LastIndex(srcKey, "/")+1:]
+		}
+		tasks = append(tasks, copyTask{SrcKey: srcKey, DstKey: dstKey, Size: size})
+	}
+
+	if len(tasks) == 0 {
+		fmt.Printf("没有需要处理的对象\n")
+		return nil
+	}
+	for _, task := range tasks {
+		if srcBucketName == dstBucketName && task.SrcKey == task.DstKey {
+			return fmt.Errorf("源和目标相同: oss://%s/%s", srcBucketName, task.SrcKey)
+		}
+	}
+
+	action := "拷贝"
+	if move {
+		action = "移动"
+	}
+	if move && cmd.Recursive {
+		keys := make([]string, len(tasks))
+		for i, task := range tasks {
+			keys[i] = task.SrcKey
+		}
+		fmt.Printf("将%s %d 个对象到 oss://%s/%s:\n", action, len(tasks), dstBucketName, dstKey)
+		printObjectSample(keys)
+		if !cmd.Force && !confirmAction("确认移动?") {
+			fmt.Printf("❌ 用户取消操作\n")
+			return nil
+		}
+	}
+
+	startTime := time.Now()
+	var copied []string
+	var totalSize int64
+	var failed int
+	for _, task := range tasks {
+		if _, err := serverSideCopy(srcBucketName, dstBucket, task); err != nil {
+			fmt.Printf("❌ %s失败 %s: %v\n", action, task.SrcKey, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ oss://%s/%s -> oss://%s/%s\n", srcBucketName, task.SrcKey, dstBucketName, task.DstKey)
+		copied = append(copied, task.SrcKey)
+		totalSize += task.Size
+	}
+
+	if move && len(copied) > 0 {
+		if _, err := deleteObjectsBatched(srcBucket, copied); err != nil {
+			return fmt.Errorf("已拷贝但删除源对象失败: %v", err)
+		}
+	}
+
+	fmt.Printf("\n🎯 %s完成: %d 个对象, %s, 耗时%.2f秒\n", action, len(copied), humanSize(totalSize), time.Since(startTime).Seconds())
+	if failed > 0 {
+		return fmt.Errorf("%d 个对象%s失败", failed, action)
+	}
+	return nil
+}
+
+// 小对象使用CopyObject，大对象使用UploadPartCopy分片拷贝
+// 返回新对象的ETag，分片拷贝时SDK不返回结果，ETag为空
+func serverSideCopy(srcBucketName string, dstBucket *oss.Bucket, task copyTask, options ...oss.Option) (string, error) {
+	if task.Size > copyMultipartThreshold {
+		return "", dstBucket.CopyFile(srcBucketName, task.SrcKey, task.DstKey, copyPartSize,
+			append(options, oss.Routines(copyRoutines))...)
+	}
+	result, err := dstBucket.CopyObjectFrom(srcBucketName, task.SrcKey, task.DstKey, options...)
+	return strings.Trim(result.ETag, `"`), err
+}
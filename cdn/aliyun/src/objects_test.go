@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOSSPath(t *testing.T) {
+	tests := []struct {
+		path       string
+		wantBucket string
+		wantKey    string
+	}{
+		{"releases/v1.0/app.apk", "default", "releases/v1.0/app.apk"},
+		{"/releases/v1.0/", "default", "releases/v1.0/"},
+		{"oss://other", "other", ""},
+		{"oss://other/", "other", ""},
+		{"oss://other/path/file", "other", "path/file"},
+		{"", "default", ""},
+	}
+	for _, tt := range tests {
+		bucket, key := parseOSSPath(tt.path, "default")
+		if bucket != tt.wantBucket || key != tt.wantKey {
+			t.Errorf("parseOSSPath(%q) = %q, %q, want %q, %q", tt.path, bucket, key, tt.wantBucket, tt.wantKey)
+		}
+	}
+}
+
+func TestRecursivePrefix(t *testing.T) {
+	tests := []struct {
+		key  string
+		raw  bool
+		want string
+	}{
+		{"releases/v1", false, "releases/v1/"},
+		{"releases/v1/", false, "releases/v1/"},
+		{"", false, ""},
+		{"releases/v1", true, "releases/v1"},
+		{"releases/v1/", true, "releases/v1/"},
+	}
+	for _, tt := range tests {
+		if got := recursivePrefix(tt.key, tt.raw); got != tt.want {
+			t.Errorf("recursivePrefix(%q, %v) = %q, want %q", tt.key, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseObjectCommand(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    *objectCommand
+		wantErr bool
+	}{
+		{[]string{"releases/"}, &objectCommand{Args: []string{"releases/"}, Delimiter: "/"}, false},
+		{[]string{"a", "-r", "--prefix", "-f"}, &objectCommand{Args: []string{"a"}, Recursive: true, RawPrefix: true, Force: true, Delimiter: "/"}, false},
+		{[]string{"-H", "--delimiter", "-", "--limit", "10"}, &objectCommand{Human: true, Delimiter: "-", Limit: 10}, false},
+		{[]string{"--limit", "0"}, nil, true},
+		{[]string{"--limit"}, nil, true},
+		{[]string{"--bogus"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseObjectCommand(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseObjectCommand(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseObjectCommand(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestDeleteObjectsBatched(t *testing.T) {
+	bucket := newTestEmulator(t)
+	for _, key := range []string{"a.txt", "dir/b.txt"} {
+		if err := bucket.PutObject(key, strings.NewReader(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 模拟器不会删除非法的key，也不在响应中列出
+	deleted, err := deleteObjectsBatched(bucket, []string{"a.txt", "dir/b.txt", "../c.txt"})
+	if deleted != 2 {
+		t.Errorf("deleteObjectsBatched() deleted %d, want 2", deleted)
+	}
+	if err == nil || !strings.Contains(err.Error(), "1 个对象删除失败: ../c.txt") {
+		t.Errorf("deleteObjectsBatched() error = %v, want the failed key", err)
+	}
+	if exists, _ := bucket.IsObjectExist("a.txt"); exists {
+		t.Errorf("a.txt still exists after delete")
+	}
+
+	if deleted, err := deleteObjectsBatched(bucket, []string{"dir/b.txt"}); deleted != 1 || err != nil {
+		t.Errorf("deleteObjectsBatched() of a deleted key = %d, %v, want 1, nil", deleted, err)
+	}
+}
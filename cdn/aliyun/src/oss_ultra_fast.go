@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -21,13 +23,42 @@ type UltraConfig struct {
 	RemoteObject    string
 	PartSize        int64
 	Routines        int
+	FileWorkers     int // 多文件上传时同时上传的文件数
 	UseAggressive   bool
-	IsDirectory     bool    // 是否为目录上传
-	UploadCount     int     // 上传文件计数
-	TotalFiles      int     // 总文件数
+	IsDirectory     bool              // 是否为多文件上传 (目录或清单)
+	ManifestFile    string            // 上传清单文件
+	UploadCount     int               // 上传文件计数
+	TotalFiles      int               // 总文件数
+	PresignTTL      time.Duration     // 上传后生成预签名URL的有效期
+	UploadedKeys    []string          // 上传成功的对象
+	FailedFiles     []string          // 上传失败的本地文件，监听模式据此重试
+	CDNBaseURL      string            // CDN基础地址，如https://cdn.example.com
+	RefreshProvider string            // 上传后刷新CDN: akamai 或 aliyun
+	AkamaiConfFile  string            // Akamai凭证文件 (akamai.conf格式)
+	AbortOnError    bool              // 分片上传失败时中止残留的分片上传
+	Multiparts      *multipartTracker // 本次运行发起的分片上传，AbortOnError时记录
+	GracePeriod     time.Duration     // 中断后等待进行中上传的时间
+	Interrupt       *uploadInterrupt  // 上传期间的中断信号处理
+	Network         NetworkConfig     // 超时、重试、代理等网络配置
+	Watch           bool              // 目录上传后持续监听并同步变化
+	Debounce        time.Duration     // 监听模式下合并连续变化的等待时间
+	Dedup           *dedupConfig      // 服务端去重，nil表示不启用
+	MetricsAddr     string            // 运行期间提供 /metrics 的地址
+	MetricsTextfile string            // 退出时写入的node_exporter textfile
+	Metrics         *uploadMetrics    // 上传指标，nil表示不统计
+	ConfigSource    string            // 凭证来源，记录在上传历史中
+	NoHistory       bool              // 不记录上传历史
+	History         *uploadHistory    // 上传历史，nil表示不记录
+	Paths           pathMapper        // 本地文件名到对象名的映射规则
+	RemoteTemplate  string            // 含模板变量的原始远程路径
+	Template        *keyTemplate      // 远程路径模板变量
 }
 
 func main() {
+	if len(os.Args) >= 2 && runSubcommand(os.Args[1], os.Args[2:]) {
+		return
+	}
+
 	if len(os.Args) < 3 {
 		showUltraUsage()
 		return
@@ -40,21 +71,88 @@ func main() {
 	}
 
 	if err := uploadUltraFast(config); err != nil {
+		if errors.Is(err, errInterrupted) {
+			os.Exit(exitInterrupted)
+		}
 		fmt.Printf("上传失败: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// 执行子命令，name不是子命令时返回false
+func runSubcommand(name string, args []string) bool {
+	var err error
+	switch name {
+	case "serve":
+		err = runServe(args)
+	case "sign":
+		err = runSign(args)
+	case "ls":
+		err = runList(args)
+	case "stat":
+		err = runStat(args)
+	case "rm":
+		err = runRemove(args)
+	case "cp":
+		err = runCopy(args)
+	case "mv":
+		err = runMove(args)
+	case "mpu":
+		err = runMultipart(args)
+	case "history":
+		err = runHistory(args)
+	default:
+		return false
+	}
+
+	if err != nil {
+		fmt.Printf("%s 失败: %v\n", name, err)
+		os.Exit(1)
+	}
+	return true
+}
+
 func showUltraUsage() {
 	fmt.Printf(`OSS极速上传工具 - 突破性能版本
 
 用法: %s <本地文件/目录> <远程路径> [选项]
+      %s --from-manifest <清单文件> [远程前缀] [选项]
+      %s ls|stat|rm|cp|mv ...  对象管理 (%s ls -h 查看详情)
+      %s mpu [前缀] [选项]       管理未完成的分片上传
+      %s sign <远程路径> [选项]  生成预签名URL
+      %s history [选项]        查询上传历史
+      %s serve [选项]          启动本地OSS模拟服务
 
 选项:
   -s SIZE     分片大小(MB)，默认1MB
   -r NUM      并发数，默认50
+  -j NUM      目录/清单模式同时上传的文件数，默认4
   -x          极限模式 (超高性能)
   -d          目录上传模式
+  --presign TTL  上传后输出预签名下载URL，如1h、7d
+  --cdn-base URL       CDN基础地址 (或环境变量OSS_CDN_BASE_URL)
+  --refresh PROVIDER   上传后刷新CDN缓存: akamai 或 aliyun
+  --akamai-conf FILE   Akamai凭证文件，默认读取AKAMAI_*环境变量
+  --abort-on-error     分片上传失败时中止本次发起的分片上传
+  --grace DURATION     Ctrl-C后等待进行中上传的时间，默认30s
+  --dedup-from PREFIX  内容与上一版本前缀下同名对象相同时，服务端复制代替上传
+  --dedup-index FILE   按内容哈希索引查找已有对象去重，并记录本次上传的内容
+  --metrics-addr ADDR  运行期间在本地地址提供Prometheus /metrics，如127.0.0.1:9464
+  --metrics-textfile FILE  退出时写入node_exporter textfile，如/var/lib/node_exporter/oss_upload.prom
+  --lowercase          由本地文件名生成的对象名转为小写
+  --rename PATTERN=REPLACEMENT  按正则重命名生成的对象名，可多次指定，如 '\.htm$=.html'
+  --no-history         不记录本次上传到上传历史
+  --watch              目录上传后持续监听，同步新增、修改和删除的文件
+  --debounce DURATION  监听模式下合并连续变化的等待时间，默认500ms
+
+网络选项:
+  --connect-timeout DURATION  建立连接超时，默认30s
+  --read-timeout DURATION     读写超时，默认60s
+  --retries NUM               请求失败后的重试次数，默认3 (指数退避+随机抖动)
+  --proxy URL                 代理，支持 http://、https://、socks5://
+  --endpoint HOST             覆盖OSS_ENDPOINT，可带协议
+  --cname                     Endpoint为绑定到bucket的自定义域名
+  --https                     Endpoint未指定协议时使用HTTPS
   -h          帮助
 
 示例:
@@ -66,26 +164,63 @@ func showUltraUsage() {
     %s ./src/ project/src/ -d
     %s ./build/ releases/v1.0/ -d -x
 
+  清单上传 (CSV/JSON/每行 "本地 -> 远程"):
+    %s --from-manifest dist/manifest.csv releases/v1.0/ -x
+
+路径映射:
+  对象名总是使用/分隔并规范化为Unicode NFC，拒绝包含 . 、.. 或空路径段的对象名
+  file.zip backups/      -> backups/file.zip (远程以/结尾时保留文件名)
+  ./build/ releases/v1/  -> releases/v1/... (本地以/结尾: 上传目录内容)
+  ./build  releases/     -> releases/build/... (本地不以/结尾: 上传目录本身)
+  ./build  releases/v1   -> releases/v1/... (都不以/结尾: 目录作为前缀)
+
+远程路径模板:
+  {date} {time} {git.sha} {git.branch} {env.NAME} {version} (VERSION文件) 每次运行展开一次
+  {file.hash} 按文件展开为内容SHA-256的前8位
+  %s ./dist/ 'releases/{version}/{git.sha}/' -d
+
+中断与续传:
+  Ctrl-C 后不再开始新文件，进行中的上传最多等待 --grace 后取消，
+  并保存断点；重新执行相同命令即可续传。再按一次 Ctrl-C 强制退出。
+  退出码: 130 中断后退出, 131 强制退出
+
 极限模式特点:
   🚀 1MB超小分片
   ⚡ 80并发连接
   💥 目标突破500KB/s
-`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
 }
 
 func parseUltraConfig() (*UltraConfig, error) {
 	config := &UltraConfig{
-		LocalPath:     cleanPath(os.Args[1]),     // 清理路径
-		RemoteObject:  cleanPath(os.Args[2]),     // 清理路径
+		LocalPath:     filepath.Clean(os.Args[1]),
 		PartSize:      1024 * 1024, // 1MB
 		Routines:      50,
+		FileWorkers:   defaultFileWorkers,
 		UseAggressive: false,
 		IsDirectory:   false,
 		UploadCount:   0,
 		TotalFiles:    0,
+		GracePeriod:   defaultGracePeriod,
+		Network:       defaultNetworkConfig(),
+		Debounce:      defaultDebounce,
+	}
+	endpoint := ""
+	remoteArg := os.Args[2]
+
+	start := 3
+	if os.Args[1] == "--from-manifest" {
+		config.ManifestFile = os.Args[2]
+		config.LocalPath = os.Args[2]
+		config.IsDirectory = true
+		remoteArg = ""
+		if len(os.Args) > 3 && !strings.HasPrefix(os.Args[3], "-") {
+			remoteArg = os.Args[3]
+			start = 4
+		}
 	}
 
-	for i := 3; i < len(os.Args); i++ {
+	for i := start; i < len(os.Args); i++ {
 		switch os.Args[i] {
 		case "-s":
 			if i+1 < len(os.Args) {
@@ -101,12 +236,147 @@ func parseUltraConfig() (*UltraConfig, error) {
 				}
 				i++
 			}
+		case "-j":
+			if i+1 < len(os.Args) {
+				if workers, err := strconv.Atoi(os.Args[i+1]); err == nil && workers > 0 {
+					config.FileWorkers = workers
+				}
+				i++
+			}
 		case "-x":
 			config.UseAggressive = true
 			config.PartSize = 1024 * 1024 // 强制1MB
 			config.Routines = 80          // 极限并发
 		case "-d":
 			config.IsDirectory = true
+		case "--abort-on-error":
+			config.AbortOnError = true
+		case "--no-history":
+			config.NoHistory = true
+		case "--lowercase":
+			config.Paths.Lowercase = true
+		case "--rename":
+			if i+1 >= len(os.Args) {
+				return nil, fmt.Errorf("--rename 需要参数")
+			}
+			rule, err := parseRenameRule(os.Args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			config.Paths.Renames = append(config.Paths.Renames, rule)
+			i++
+		case "--presign":
+			if i+1 >= len(os.Args) {
+				return nil, fmt.Errorf("--presign 需要指定有效期")
+			}
+			ttl, err := parseTTL(os.Args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			config.PresignTTL = ttl
+			i++
+		case "--connect-timeout", "--read-timeout":
+			if i+1 >= len(os.Args) {
+				return nil, fmt.Errorf("%s 需要指定超时时间", os.Args[i])
+			}
+			timeout, err := parseTTL(os.Args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if os.Args[i] == "--connect-timeout" {
+				config.Network.ConnectTimeout = timeout
+			} else {
+				config.Network.ReadTimeout = timeout
+			}
+			i++
+		case "--retries":
+			if i+1 >= len(os.Args) {
+				return nil, fmt.Errorf("--retries 需要指定次数")
+			}
+			retries, err := strconv.Atoi(os.Args[i+1])
+			if err != nil || retries < 0 {
+				return nil, fmt.Errorf("无效的重试次数: %s", os.Args[i+1])
+			}
+			config.Network.Retries = retries
+			i++
+		case "--proxy", "--endpoint":
+			if i+1 >= len(os.Args) {
+				return nil, fmt.Errorf("%s 需要参数", os.Args[i])
+			}
+			if os.Args[i] == "--proxy" {
+				if _, err := parseProxyURL(os.Args[i+1]); err != nil {
+					return nil, err
+				}
+				config.Network.Proxy = os.Args[i+1]
+			} else {
+				endpoint = os.Args[i+1]
+			}
+			i++
+		case "--cname":
+			config.Network.UseCname = true
+		case "--https":
+			config.Network.UseHTTPS = true
+		case "--watch":
+			config.Watch = true
+		case "--debounce":
+			if i+1 >= len(os.Args) {
+				return nil, fmt.Errorf("--debounce 需要指定等待时间")
+			}
+			debounce, err := parseTTL(os.Args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			config.Debounce = debounce
+			i++
+		case "--dedup-from", "--dedup-index":
+			if i+1 >= len(os.Args) {
+				return nil, fmt.Errorf("%s 需要参数", os.Args[i])
+			}
+			if config.Dedup == nil {
+				config.Dedup = &dedupConfig{}
+			}
+			if os.Args[i] == "--dedup-from" {
+				config.Dedup.Prefix = normalizeDedupPrefix(os.Args[i+1])
+			} else {
+				config.Dedup.IndexFile = os.Args[i+1]
+			}
+			i++
+		case "--metrics-addr", "--metrics-textfile":
+			if i+1 >= len(os.Args) {
+				return nil, fmt.Errorf("%s 需要参数", os.Args[i])
+			}
+			if os.Args[i] == "--metrics-addr" {
+				config.MetricsAddr = os.Args[i+1]
+			} else {
+				config.MetricsTextfile = os.Args[i+1]
+			}
+			i++
+		case "--grace":
+			if i+1 >= len(os.Args) {
+				return nil, fmt.Errorf("--grace 需要指定等待时间")
+			}
+			grace, err := parseTTL(os.Args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			config.GracePeriod = grace
+			i++
+		case "--cdn-base", "--refresh", "--akamai-conf":
+			if i+1 >= len(os.Args) {
+				return nil, fmt.Errorf("%s 需要参数", os.Args[i])
+			}
+			switch os.Args[i] {
+			case "--cdn-base":
+				config.CDNBaseURL = os.Args[i+1]
+			case "--refresh":
+				if os.Args[i+1] != "akamai" && os.Args[i+1] != "aliyun" {
+					return nil, fmt.Errorf("--refresh 只支持 akamai 或 aliyun")
+				}
+				config.RefreshProvider = os.Args[i+1]
+			case "--akamai-conf":
+				config.AkamaiConfFile = os.Args[i+1]
+			}
+			i++
 		case "-h":
 			showUltraUsage()
 			os.Exit(0)
@@ -120,33 +390,59 @@ func parseUltraConfig() (*UltraConfig, error) {
 		}
 	}
 
-	if err := loadUltraOSSConfig(config); err != nil {
+	// 运行级模板变量在此展开一次，{file.hash} 在上传每个文件时展开
+	config.Template = newKeyTemplate(config.LocalPath)
+	if hasTemplate(remoteArg) {
+		expanded, err := config.Template.expand(remoteArg)
+		if err != nil {
+			return nil, err
+		}
+		config.RemoteTemplate, remoteArg = remoteArg, expanded
+	}
+
+	remote, err := parseRemotePath(remoteArg)
+	if err != nil {
 		return nil, err
 	}
+	if config.ManifestFile != "" {
+		// 清单模式的远程路径总是前缀
+		if remote != "" && !strings.HasSuffix(remote, "/") {
+			remote += "/"
+		}
+		config.RemoteObject = remote
+	} else if config.RemoteObject, err = resolveRemoteTarget(&config.Paths, os.Args[1], remote, config.IsDirectory); err != nil {
+		return nil, err
+	} else if config.IsDirectory {
+		if legacy := legacyDirectoryTarget(remote); legacy != config.RemoteObject {
+			fmt.Printf("⚠️  目录将上传到 %s (旧版本为 %s)；如需上传目录内容到 %s，请在本地路径末尾加 /\n", config.RemoteObject, legacy, legacy)
+		}
+	} else {
+		if config.RemoteObject, err = config.Template.expandFile(config.RemoteObject, config.LocalPath); err != nil {
+			return nil, err
+		}
+	}
 
-	return config, nil
-}
+	if config.Dedup != nil {
+		if err := loadDedupIndex(config.Dedup); err != nil {
+			return nil, err
+		}
+	}
+	if config.Watch && (!config.IsDirectory || config.ManifestFile != "") {
+		return nil, fmt.Errorf("--watch 只支持目录上传")
+	}
 
-// 清理Git Bash自动添加的路径前缀
-func cleanPath(path string) string {
-	// 移除Git Bash添加的前缀
-	if strings.HasPrefix(path, "C:/Program Files/Git/") {
-		path = strings.TrimPrefix(path, "C:/Program Files/Git")
+	if err := loadUltraOSSConfig(config); err != nil {
+		return nil, err
 	}
-	if strings.HasPrefix(path, "/c/Program Files/Git/") {
-		path = strings.TrimPrefix(path, "/c/Program Files/Git")
+	if endpoint != "" {
+		config.Endpoint = endpoint
 	}
-	
-	// 确保路径以正确的格式开始
-	if strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") {
-		// Unix风格路径，保持不变
-		return path
+	resolveCDNBaseURL(config)
+	if config.RefreshProvider != "" && config.CDNBaseURL == "" {
+		return nil, fmt.Errorf("CDN刷新需要通过 --cdn-base 或 OSS_CDN_BASE_URL 指定CDN地址")
 	}
-	
-	// 清理多余的斜杠
-	path = strings.TrimPrefix(path, "/")
-	
-	return path
+
+	return config, nil
 }
 
 func loadUltraOSSConfig(config *UltraConfig) error {
@@ -154,11 +450,13 @@ func loadUltraOSSConfig(config *UltraConfig) error {
 	config.AccessKeyID = os.Getenv("OSS_ACCESS_KEY_ID")
 	config.AccessKeySecret = os.Getenv("OSS_ACCESS_KEY_SECRET")
 	config.BucketName = os.Getenv("OSS_BUCKET")
+	config.ConfigSource = "env"
 
 	if config.AccessKeyID == "" {
 		if err := loadUltraFromOSSUtilConfig(config); err != nil {
 			return fmt.Errorf("无法获取OSS配置: %v", err)
 		}
+		config.ConfigSource = "~/.ossutilconfig"
 	}
 
 	if config.Endpoint == "" {
@@ -205,105 +503,156 @@ func loadUltraFromOSSUtilConfig(config *UltraConfig) error {
 	return nil
 }
 
-func uploadUltraFast(config *UltraConfig) error {
-	client, err := oss.New(config.Endpoint, config.AccessKeyID, config.AccessKeySecret)
+// 根据配置创建OSS客户端
+func newUltraClient(config *UltraConfig) (*oss.Client, error) {
+	httpClient, err := newUltraHTTPClient(config)
 	if err != nil {
-		return fmt.Errorf("创建OSS客户端失败: %v", err)
+		return nil, err
+	}
+	client, err := oss.New(endpointURL(config), config.AccessKeyID, config.AccessKeySecret,
+		oss.HTTPClient(httpClient), oss.UseCname(config.Network.UseCname))
+	if err != nil {
+		return nil, fmt.Errorf("创建OSS客户端失败: %v", err)
 	}
+	return client, nil
+}
 
-	bucket, err := client.Bucket(config.BucketName)
+// 根据配置创建bucket客户端
+func newUltraBucket(config *UltraConfig) (*oss.Bucket, error) {
+	client, err := newUltraClient(config)
 	if err != nil {
-		return fmt.Errorf("获取bucket失败: %v", err)
+		return nil, err
 	}
 
-	if config.IsDirectory {
-		return uploadDirectory(config, bucket)
-	} else {
-		return uploadSingleFile(config, bucket, config.LocalPath, config.RemoteObject)
+	bucket, err := client.Bucket(config.BucketName)
+	if err != nil {
+		return nil, fmt.Errorf("获取bucket失败: %v", err)
 	}
-}
 
-func uploadDirectory(config *UltraConfig, bucket *oss.Bucket) error {
-	fmt.Printf("🚀 极速目录上传模式启动\n")
-	fmt.Printf("目录: %s\n", config.LocalPath)
-	fmt.Printf("目标: oss://%s/%s\n", config.BucketName, config.RemoteObject)
+	return bucket, nil
+}
 
-	if config.UseAggressive {
-		fmt.Printf("💥 极限模式: %dMB分片, %d并发\n", 
-			config.PartSize/1024/1024, config.Routines)
+func uploadUltraFast(config *UltraConfig) (err error) {
+	config.Interrupt = newUploadInterrupt(config.GracePeriod)
+	defer config.Interrupt.Close()
+	if config.AbortOnError {
+		config.Multiparts = newMultipartTracker()
 	}
 
-	// 收集所有文件
-	var files []string
-	err := filepath.Walk(config.LocalPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			files = append(files, path)
+	if config.MetricsAddr != "" || config.MetricsTextfile != "" {
+		config.Metrics = newUploadMetrics()
+		if config.MetricsAddr != "" {
+			if err := config.Metrics.serve(config.MetricsAddr); err != nil {
+				return err
+			}
 		}
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("扫描目录失败: %v", err)
+		defer func() {
+			config.Metrics.finish(err == nil, config.MetricsTextfile)
+		}()
 	}
 
-	config.TotalFiles = len(files)
-	fmt.Printf("📁 发现 %d 个文件\n", config.TotalFiles)
-
-	startTime := time.Now()
+	if !config.NoHistory {
+		config.History = newUploadHistory(config)
+		defer func() {
+			config.History.flush("", err)
+		}()
+	}
 
-	// 上传所有文件
-	for _, filePath := range files {
-		relPath, err := filepath.Rel(config.LocalPath, filePath)
-		if err != nil {
-			return fmt.Errorf("计算相对路径失败: %v", err)
+	bucket, err := newUltraBucket(config)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := config.Dedup.saveIndex(); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
 		}
-		
-		// 构建远程路径
-		remotePath := filepath.Join(config.RemoteObject, relPath)
-		remotePath = strings.ReplaceAll(remotePath, "\\", "/") // 确保使用正斜杠
+	}()
 
-		config.UploadCount++
-		fmt.Printf("\n📤 [%d/%d] %s\n", config.UploadCount, config.TotalFiles, relPath)
+	// 监听模式以上传前的扫描结果为基准，首次同步期间的变化也会被发现
+	var synced map[string]fileStamp
+	if config.Watch {
+		if synced, err = scanTree(config.LocalPath); err != nil {
+			return fmt.Errorf("扫描目录失败: %v", err)
+		}
+	}
 
-		if err := uploadSingleFile(config, bucket, filePath, remotePath); err != nil {
-			fmt.Printf("❌ 上传失败: %v\n", err)
-			continue
+	if config.ManifestFile != "" {
+		err = uploadManifest(config, bucket)
+	} else if config.IsDirectory {
+		err = uploadDirectory(config, bucket)
+	} else if err = uploadSingleFile(config, bucket, config.LocalPath, config.RemoteObject); err == nil {
+		config.UploadedKeys = append(config.UploadedKeys, config.RemoteObject)
+	} else if errors.Is(err, errInterrupted) {
+		config.Metrics.observeFile("interrupted")
+	} else {
+		config.Metrics.observeFile("failed")
+	}
+	if errors.Is(err, errInterrupted) {
+		if !config.IsDirectory {
+			fmt.Printf("\n⏸️  %v\n", err)
 		}
+		return errInterrupted
+	}
+	// 部分文件失败时仍刷新已上传的对象，监听模式继续运行并重试失败的文件
+	var uploadErr error
+	if errors.As(err, new(*filesFailedError)) {
+		uploadErr, err = err, nil
+	}
+	if err != nil {
+		return err
+	}
+	if config.Interrupt.Stopped() {
+		if config.RefreshProvider != "" {
+			fmt.Printf("⏸️  已中断，跳过CDN刷新\n")
+		}
+		return errInterrupted
 	}
 
-	duration := time.Since(startTime)
-	
-	fmt.Printf("\n🎯 目录上传完成！\n")
-	fmt.Printf("总文件: %d 个\n", config.TotalFiles)
-	fmt.Printf("成功上传: %d 个\n", config.UploadCount)
-	fmt.Printf("总耗时: %.2f秒\n", duration.Seconds())
-	fmt.Printf("平均速度: %.2f 文件/秒\n", float64(config.UploadCount)/duration.Seconds())
-
-	fmt.Printf("\nOSS目录: https://%s.%s/%s\n", 
-		config.BucketName, config.Endpoint, config.RemoteObject)
-	
-	if config.BucketName == "oss-mh" {
-		fmt.Printf("CDN目录: https://cdn-mh.hwrescdn.com/%s\n", config.RemoteObject)
+	if err := refreshCDNAfterUpload(config); err != nil {
+		return err
 	}
+	if config.Watch {
+		config.History.flush("", uploadErr)
+		return watchDirectory(config, bucket, synced)
+	}
+	return uploadErr
+}
 
-	return nil
+func uploadDirectory(config *UltraConfig, bucket *oss.Bucket) error {
+	fmt.Printf("🚀 极速目录上传模式启动\n")
+	fmt.Printf("目录: %s\n", config.LocalPath)
+	fmt.Printf("目标: oss://%s/%s\n", config.BucketName, config.RemoteObject)
+	config.Template.print(config.RemoteTemplate)
+
+	if config.UseAggressive {
+		fmt.Printf("💥 极限模式: %dMB分片, %d并发\n", 
+			config.PartSize/1024/1024, config.Routines)
+	}
+
+	// 边扫描边上传，扫描完成后再显示总数
+	fmt.Printf("📁 边扫描边上传 (%d个文件并发)\n", config.FileWorkers)
+	return uploadEntries(config, bucket, walkDirectory(config), "目录上传")
 }
 
-func uploadSingleFile(config *UltraConfig, bucket *oss.Bucket, localFile, remoteObject string) error {
+func uploadSingleFile(config *UltraConfig, bucket *oss.Bucket, localFile, remoteObject string, options ...oss.Option) (err error) {
+	var fileSize int64
+	etag, result := "", "uploaded"
+	defer func() {
+		config.History.observeFile(historyFile{Local: localFile, Key: remoteObject, Size: fileSize, ETag: etag, Result: result}, err)
+	}()
+
 	fileInfo, err := os.Stat(localFile)
 	if err != nil {
 		return fmt.Errorf("文件不存在: %v", err)
 	}
 
-	fileSize := fileInfo.Size()
-	
+	fileSize = fileInfo.Size()
+
 	if !config.IsDirectory {
 		fmt.Printf("🚀 极速上传模式启动\n")
 		fmt.Printf("文件: %s (%.2f MB)\n", localFile, float64(fileSize)/1024/1024)
 		fmt.Printf("目标: oss://%s/%s\n", config.BucketName, remoteObject)
+		config.Template.print(config.RemoteTemplate)
 
 		if config.UseAggressive {
 			fmt.Printf("💥 极限模式: %dMB分片, %d并发\n", 
@@ -311,6 +660,21 @@ func uploadSingleFile(config *UltraConfig, bucket *oss.Bucket, localFile, remote
 		}
 	}
 
+	// 服务端去重: 内容已存在时直接复制
+	var digest contentDigest
+	if config.Dedup != nil {
+		if digest, err = fileDigest(localFile); err != nil {
+			return fmt.Errorf("计算文件摘要失败: %v", err)
+		}
+		if copied, copiedETag := dedupCopy(config, bucket, digest, remoteObject, options); copied {
+			result, etag = "deduped", copiedETag
+			if !config.IsDirectory {
+				fmt.Printf("\nOSS地址: %s\n", objectURL(config, remoteObject))
+			}
+			return nil
+		}
+	}
+	
 	startTime := time.Now()
 
 	// 根据文件大小和模式选择策略
@@ -318,7 +682,15 @@ func uploadSingleFile(config *UltraConfig, bucket *oss.Bucket, localFile, remote
 		if !config.IsDirectory {
 			fmt.Printf("策略: 直接上传\n")
 		}
-		err = bucket.PutObjectFromFile(remoteObject, localFile)
+		var respHeader http.Header
+		err = withRetry(config, "上传 "+remoteObject, func() error {
+			return bucket.PutObjectFromFile(remoteObject, localFile,
+				append(options, oss.GetResponseHeader(&respHeader))...)
+		})
+		if err != nil && config.Interrupt.Stopped() {
+			return errInterrupted
+		}
+		etag = strings.Trim(respHeader.Get(oss.HTTPHeaderEtag), `"`)
 	} else {
 		if !config.IsDirectory {
 			fmt.Printf("策略: 极速分片 (%dMB/%d并发)\n", 
@@ -331,16 +703,42 @@ func uploadSingleFile(config *UltraConfig, bucket *oss.Bucket, localFile, remote
 			isDirectory: config.IsDirectory,
 		}
 
-		err = bucket.UploadFile(remoteObject, localFile, config.PartSize,
-			oss.Routines(config.Routines),
-			oss.Progress(progress))
+		// 启用断点，中断后重新执行相同命令可从已完成的分片续传
+		cpFile := checkpointPath(config, remoteObject)
+		if err := os.MkdirAll(filepath.Dir(cpFile), 0755); err != nil {
+			return fmt.Errorf("创建断点目录失败: %v", err)
+		}
+		// 分片失败后重试会从断点继续，只重传未完成的分片
+		// 各分片使用自己的响应头，respHeader最后是CompleteMultipartUpload的响应
+		var respHeader http.Header
+		err = withRetry(config, "分片上传 "+remoteObject, func() error {
+			return bucket.UploadFile(remoteObject, localFile, config.PartSize,
+				append(options,
+					oss.Routines(config.Routines),
+					oss.Checkpoint(true, cpFile),
+					oss.Progress(progress),
+					oss.GetResponseHeader(&respHeader))...)
+		})
+		if err != nil && config.Interrupt.Stopped() {
+			return fmt.Errorf("%w，断点已保存，重新执行相同命令可续传", errInterrupted)
+		}
+		if err != nil && config.AbortOnError {
+			uploadIDs := append(config.Multiparts.take(remoteObject), checkpointUploadID(cpFile))
+			abortOwnMultiparts(bucket, remoteObject, uploadIDs)
+			os.Remove(cpFile)
+		}
+		if err == nil {
+			etag = strings.Trim(respHeader.Get(oss.HTTPHeaderEtag), `"`)
+		}
 	}
 
 	if err != nil {
 		return fmt.Errorf("上传过程失败: %v", err)
 	}
+	config.Dedup.record(digest, remoteObject)
 
 	duration := time.Since(startTime)
+	config.Metrics.observeUpload(fileSize, duration)
 	speed := float64(fileSize) / duration.Seconds() / 1024 / 1024
 
 	if !config.IsDirectory {
@@ -363,12 +761,13 @@ func uploadSingleFile(config *UltraConfig, bucket *oss.Bucket, localFile, remote
 			fmt.Printf("建议: 使用极限模式 -x\n")
 		}
 
-		fmt.Printf("\nOSS地址: https://%s.%s/%s\n", 
-			config.BucketName, config.Endpoint, remoteObject)
+		fmt.Printf("\nOSS地址: %s\n", objectURL(config, remoteObject))
 		
-		if config.BucketName == "oss-mh" {
-			fmt.Printf("CDN地址: https://cdn-mh.hwrescdn.com/%s\n", remoteObject)
+		if config.CDNBaseURL != "" {
+			fmt.Printf("CDN地址: %s\n", cdnURL(config, remoteObject))
 		}
+
+		printPresignedURLs(config, bucket, []string{remoteObject})
 	}
 
 	return nil
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxObjectKeyLength = 1023 // OSS对象名最长1023字节
+
+// Git Bash (MSYS) 会把以/开头的参数转换为Windows路径，远程路径需要还原
+// 也可以设置 MSYS_NO_PATHCONV=1 关闭转换
+var gitBashPrefixes = []string{"C:/Program Files/Git/", "/c/Program Files/Git/"}
+
+// 本地文件名到对象名的映射规则，只作用于由本地文件名生成的部分，
+// 命令行或清单中明确写出的远程路径保持原样
+type pathMapper struct {
+	Lowercase bool
+	Renames   []renameRule // 按顺序依次应用，在转小写之后
+}
+
+type renameRule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// 解析 --rename PATTERN=REPLACEMENT，PATTERN为正则，REPLACEMENT可引用$1等分组
+func parseRenameRule(value string) (renameRule, error) {
+	pattern, replacement, ok := strings.Cut(value, "=")
+	if !ok || pattern == "" {
+		return renameRule{}, fmt.Errorf("--rename 格式应为 PATTERN=REPLACEMENT: %s", value)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return renameRule{}, fmt.Errorf("--rename 正则无效: %v", err)
+	}
+	return renameRule{Pattern: re, Replacement: replacement}, nil
+}
+
+// 把本地相对路径映射为对象名的相对部分
+func (m *pathMapper) mapRelative(relPath string) string {
+	key := norm.NFC.String(filepath.ToSlash(relPath))
+	if m.Lowercase {
+		key = strings.ToLower(key)
+	}
+	for _, rule := range m.Renames {
+		key = rule.Pattern.ReplaceAllString(key, rule.Replacement)
+	}
+	return key
+}
+
+// 规范化命令行中的远程路径: 还原Git Bash转换、统一为/、NFC、去掉开头的/
+// 保留结尾的/，由调用方决定"上传到前缀下"还是"作为对象名"
+func normalizeRemotePath(value string) string {
+	value = strings.ReplaceAll(value, "\\", "/")
+	for _, prefix := range gitBashPrefixes {
+		if len(value) >= len(prefix) && strings.EqualFold(value[:len(prefix)], prefix) {
+			value = "/" + value[len(prefix):]
+			break
+		}
+	}
+	return strings.TrimLeft(norm.NFC.String(value), "/")
+}
+
+// 规范化并检查远程路径，允许为空 (bucket根目录) 和以/结尾 (前缀)
+func parseRemotePath(value string) (string, error) {
+	remote := normalizeRemotePath(value)
+	if remote == "" {
+		return "", nil
+	}
+	if err := validateObjectKey(strings.TrimSuffix(remote, "/")); err != nil {
+		return "", fmt.Errorf("无效的远程路径 %q: %v", value, err)
+	}
+	return remote, nil
+}
+
+// 旧版本的目录上传总是把目录内容放到远程前缀下，不区分本地路径结尾的/。
+// 返回旧规则下的前缀，与resolveRemoteTarget的结果不同时需要提醒用户
+func legacyDirectoryTarget(remote string) string {
+	if remote != "" && !strings.HasSuffix(remote, "/") {
+		remote += "/"
+	}
+	return remote
+}
+
+// 检查对象名: 不允许空段 (//)、.和..，长度不超过OSS限制
+func validateObjectKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("对象名为空")
+	}
+	if len(key) > maxObjectKeyLength {
+		return fmt.Errorf("对象名超过%d字节", maxObjectKeyLength)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		switch segment {
+		case "":
+			return fmt.Errorf("包含空的路径段")
+		case ".", "..":
+			return fmt.Errorf("包含 %s 路径段", segment)
+		}
+	}
+	return nil
+}
+
+// 拼接前缀和相对部分并检查结果
+func joinObjectKey(prefix, relKey string) (string, error) {
+	key := relKey
+	if prefix != "" {
+		key = strings.TrimSuffix(prefix, "/") + "/" + relKey
+	}
+	if err := validateObjectKey(key); err != nil {
+		return "", fmt.Errorf("无效的对象名 %q: %v", key, err)
+	}
+	return key, nil
+}
+
+// 本地路径是否以路径分隔符结尾，如 ./build/
+func hasTrailingSeparator(localPath string) bool {
+	return strings.HasSuffix(localPath, "/") || strings.HasSuffix(localPath, string(filepath.Separator))
+}
+
+// 确定上传目标:
+//   - 单文件: 远程路径为空或以/结尾时上传到该前缀下，保留文件名；否则作为对象名
+//   - 目录: 本地路径以/结尾时上传目录内容；本地路径不以/结尾且远程路径以/结尾时，
+//     目录本身上传到前缀下 (build releases/ -> releases/build/...)；都不以/结尾时目录作为前缀
+//
+// 目录模式返回的前缀为空或以/结尾
+func resolveRemoteTarget(mapper *pathMapper, localArg, remote string, isDirectory bool) (string, error) {
+	base := filepath.Base(filepath.Clean(localArg))
+	if !isDirectory {
+		if remote == "" || strings.HasSuffix(remote, "/") {
+			return joinObjectKey(remote, mapper.mapRelative(base))
+		}
+		return remote, validateObjectKey(remote)
+	}
+
+	isRoot := base == "." || base == ".." || base == string(filepath.Separator)
+	if strings.HasSuffix(remote, "/") && !hasTrailingSeparator(localArg) && !isRoot {
+		remote += mapper.mapRelative(base)
+	}
+	if remote != "" && !strings.HasSuffix(remote, "/") {
+		remote += "/"
+	}
+	return remote, nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRemotePath(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"releases/v1/app.apk", "releases/v1/app.apk"},
+		{"/releases/v1/", "releases/v1/"},
+		{"//releases", "releases"},
+		{`releases\v1\app.apk`, "releases/v1/app.apk"},
+		{"C:/Program Files/Git/releases/v1/", "releases/v1/"},
+		{"c:/program files/git/releases", "releases"},
+		{"/c/Program Files/Git/releases", "releases"},
+		{"C:/Users/releases", "C:/Users/releases"},
+		{"cafe\u0301.txt", "caf\u00e9.txt"}, // macOS的NFD文件名转为NFC
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeRemotePath(tt.value); got != tt.want {
+			t.Errorf("normalizeRemotePath(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValidateObjectKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{"a.txt", false},
+		{"releases/v1.0/app.apk", false},
+		{"..a/b..", false},
+		{strings.Repeat("a", maxObjectKeyLength), false},
+		{strings.Repeat("a", maxObjectKeyLength+1), true},
+		{"", true},
+		{"a//b", true},
+		{"a/", true},
+		{"./a", true},
+		{"a/../b", true},
+	}
+	for _, tt := range tests {
+		if err := validateObjectKey(tt.key); (err != nil) != tt.wantErr {
+			t.Errorf("validateObjectKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseRemotePath(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"/", "", false},
+		{"releases/", "releases/", false},
+		{"/releases/app.apk", "releases/app.apk", false},
+		{"releases//app.apk", "", true},
+		{"releases/../app.apk", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseRemotePath(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRemotePath(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseRemotePath(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestJoinObjectKey(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		relKey  string
+		want    string
+		wantErr bool
+	}{
+		{"", "a.txt", "a.txt", false},
+		{"releases", "a.txt", "releases/a.txt", false},
+		{"releases/", "v1/a.txt", "releases/v1/a.txt", false},
+		{"releases/", "", "", true},
+		{"releases/", "../a.txt", "", true},
+	}
+	for _, tt := range tests {
+		got, err := joinObjectKey(tt.prefix, tt.relKey)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("joinObjectKey(%q, %q) error = %v, wantErr %v", tt.prefix, tt.relKey, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("joinObjectKey(%q, %q) = %q, want %q", tt.prefix, tt.relKey, got, tt.want)
+		}
+	}
+}
+
+func TestParseRenameRule(t *testing.T) {
+	tests := []struct {
+		value   string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{`\.min\.js$=.js`, "a.min.js", "a.js", false},
+		{`^(\w+)-(\d+)=$2/$1`, "app-42.apk", "42/app.apk", false},
+		{`a==b`, "a=b", "=b=b", false}, // 只按第一个=分割
+		{`tmp=`, "tmp/a", "/a", false},
+		{`=x`, "", "", true},
+		{`no-separator`, "", "", true},
+		{`([a-z=x`, "", "", true},
+	}
+	for _, tt := range tests {
+		rule, err := parseRenameRule(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRenameRule(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := rule.Pattern.ReplaceAllString(tt.input, rule.Replacement); got != tt.want {
+			t.Errorf("parseRenameRule(%q) applied to %q = %q, want %q", tt.value, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMapRelative(t *testing.T) {
+	mapper := &pathMapper{
+		Lowercase: true,
+		Renames:   []renameRule{{Pattern: regexp.MustCompile(` `), Replacement: "-"}},
+	}
+	tests := []struct {
+		mapper  *pathMapper
+		relPath string
+		want    string
+	}{
+		{&pathMapper{}, "Img/Logo.PNG", "Img/Logo.PNG"},
+		{mapper, "Img/My Logo.PNG", "img/my-logo.png"},
+		{mapper, "cafe\u0301.txt", "caf\u00e9.txt"},
+	}
+	for _, tt := range tests {
+		if got := tt.mapper.mapRelative(tt.relPath); got != tt.want {
+			t.Errorf("mapRelative(%q) = %q, want %q", tt.relPath, got, tt.want)
+		}
+	}
+}
+
+func TestResolveRemoteTarget(t *testing.T) {
+	plain := &pathMapper{}
+	lower := &pathMapper{Lowercase: true}
+	tests := []struct {
+		mapper      *pathMapper
+		localArg    string
+		remote      string
+		isDirectory bool
+		want        string
+		wantErr     bool
+	}{
+		{plain, "dist/app.js", "", false, "app.js", false},
+		{plain, "dist/app.js", "static/", false, "static/app.js", false},
+		{plain, "dist/app.js", "static/main.js", false, "static/main.js", false},
+		{lower, "dist/App.JS", "static/", false, "static/app.js", false},
+		{lower, "dist/App.JS", "static/Main.JS", false, "static/Main.JS", false}, // 明确写出的远程路径保持原样
+		{plain, "dist/app.js", "a//b.js", false, "", true},
+		{plain, "build", "releases/", true, "releases/build/", false},
+		{plain, "build/", "releases/", true, "releases/", false},
+		{plain, "build", "releases", true, "releases/", false},
+		{plain, "build", "", true, "", false},
+		{plain, ".", "releases/", true, "releases/", false},
+		{lower, "Build", "releases/", true, "releases/build/", false},
+	}
+	for _, tt := range tests {
+		got, err := resolveRemoteTarget(tt.mapper, tt.localArg, tt.remote, tt.isDirectory)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("resolveRemoteTarget(%q, %q, %v) error = %v, wantErr %v", tt.localArg, tt.remote, tt.isDirectory, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolveRemoteTarget(%q, %q, %v) = %q, want %q", tt.localArg, tt.remote, tt.isDirectory, got, tt.want)
+		}
+	}
+}
+
+func TestLegacyDirectoryTarget(t *testing.T) {
+	// 只有本地不以/结尾、远程以/结尾时与旧版本不同
+	tests := []struct {
+		localArg    string
+		remote      string
+		wantChanged bool
+	}{
+		{"build", "releases/", true},
+		{"build/", "releases/", false},
+		{"build", "releases", false},
+		{"build", "", false},
+		{".", "releases/", false},
+	}
+	for _, tt := range tests {
+		got, err := resolveRemoteTarget(&pathMapper{}, tt.localArg, tt.remote, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed := legacyDirectoryTarget(tt.remote) != got; changed != tt.wantChanged {
+			t.Errorf("legacyDirectoryTarget(%q) = %q, resolveRemoteTarget(%q) = %q, want changed %v", tt.remote, legacyDirectoryTarget(tt.remote), tt.localArg, got, tt.wantChanged)
+		}
+	}
+}
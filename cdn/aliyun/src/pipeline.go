@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+const (
+	defaultFileWorkers = 4
+	scanQueueSize      = 1000 // 扫描结果的缓冲，扫描快于上传时在此阻塞
+)
+
+// 一个待上传的文件
+type uploadEntry struct {
+	Name      string // 进度显示和续传记录使用的名称
+	LocalPath string
+	Remote    string
+	Headers   map[string]string // 额外的请求头，如Cache-Control
+}
+
+func (e uploadEntry) options() []oss.Option {
+	names := make([]string, 0, len(e.Headers))
+	for name := range e.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	options := make([]oss.Option, 0, len(names))
+	for _, name := range names {
+		options = append(options, oss.SetHeader(name, e.Headers[name]))
+	}
+	return options
+}
+
+// 上传任务来源，生产者边扫描边写入entries，完成后关闭
+type entrySource struct {
+	entries chan uploadEntry
+	found   int64 // 已发现的文件数
+	done    int32 // 扫描是否已结束
+	err     error // 扫描错误，entries关闭后可读
+}
+
+func newEntrySource() *entrySource {
+	return &entrySource{entries: make(chan uploadEntry, scanQueueSize)}
+}
+
+// 写入一个任务，收到中断信号时返回false
+func (s *entrySource) push(interrupt *uploadInterrupt, entry uploadEntry) bool {
+	select {
+	case s.entries <- entry:
+		atomic.AddInt64(&s.found, 1)
+		return true
+	case <-interrupt.stop:
+		return false
+	}
+}
+
+func (s *entrySource) finish(err error) {
+	s.err = err
+	atomic.StoreInt32(&s.done, 1)
+	close(s.entries)
+}
+
+// 总数，扫描未结束时返回-1
+func (s *entrySource) total() int64 {
+	if atomic.LoadInt32(&s.done) == 0 {
+		return -1
+	}
+	return atomic.LoadInt64(&s.found)
+}
+
+// 已知的任务列表，如清单
+func sliceSource(entries []uploadEntry) *entrySource {
+	source := &entrySource{entries: make(chan uploadEntry, len(entries)), found: int64(len(entries))}
+	for _, entry := range entries {
+		source.entries <- entry
+	}
+	source.finish(nil)
+	return source
+}
+
+var errScanStopped = errors.New("扫描已中止")
+
+// 部分文件上传失败，其余文件已上传，续传状态已保存
+type filesFailedError struct {
+	Count int
+}
+
+func (e *filesFailedError) Error() string {
+	return fmt.Sprintf("%d 个文件上传失败", e.Count)
+}
+
+// 后台扫描目录，发现的文件立即交给上传
+func walkDirectory(config *UltraConfig) *entrySource {
+	source := newEntrySource()
+	go func() {
+		err := filepath.Walk(config.LocalPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(config.LocalPath, path)
+			if err != nil {
+				return fmt.Errorf("计算相对路径失败: %v", err)
+			}
+			remote, err := directoryRemoteKey(config, relPath)
+			if err != nil {
+				return err
+			}
+			entry := uploadEntry{Name: relPath, LocalPath: path, Remote: remote}
+			if !source.push(config.Interrupt, entry) {
+				return errScanStopped
+			}
+			return nil
+		})
+
+		if err == nil {
+			fmt.Printf("\n📁 扫描完成，共 %d 个文件\n", atomic.LoadInt64(&source.found))
+		} else if err != errScanStopped {
+			err = fmt.Errorf("扫描目录失败: %v", err)
+			fmt.Printf("\n❌ %v\n", err)
+		}
+		source.finish(err)
+	}()
+	return source
+}
+
+// 多个worker并发上传任务，支持续传、中断和汇总报告
+func uploadEntries(config *UltraConfig, bucket *oss.Bucket, source *entrySource, label string) error {
+	// 上次中断时已完成的文件
+	state := loadUploadState(config)
+	if len(state.Completed) > 0 {
+		fmt.Printf("♻️  发现 %s 的中断记录，跳过未变化的已上传文件\n", state.InterruptedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	startTime := time.Now()
+	skipped, failed := 0, 0
+	var mutex sync.Mutex // 保护计数、state和config.UploadedKeys
+
+	workers := config.FileWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range source.entries {
+				// 中断后只取出剩余任务，不再上传
+				if config.Interrupt.Stopped() {
+					continue
+				}
+
+				info, err := os.Stat(entry.LocalPath)
+				mutex.Lock()
+				config.UploadCount++
+				index := config.UploadCount
+				if err == nil && state.isCompleted(entry.Name, info) {
+					skipped++
+					config.Metrics.observeFile("skipped")
+					mutex.Unlock()
+					continue
+				}
+				mutex.Unlock()
+
+				total := "?"
+				if n := source.total(); n >= 0 {
+					total = fmt.Sprint(n)
+				}
+				fmt.Printf("\n📤 [%d/%s] %s\n", index, total, entry.Name)
+
+				err = uploadSingleFile(config, bucket, entry.LocalPath, entry.Remote, entry.options()...)
+				mutex.Lock()
+				switch {
+				case errors.Is(err, errInterrupted):
+					fmt.Printf("⏸️  已中断 %s: %v\n", entry.Name, err)
+					config.Metrics.observeFile("interrupted")
+				case err != nil:
+					fmt.Printf("❌ 上传失败 %s: %v\n", entry.Name, err)
+					failed++
+					config.FailedFiles = append(config.FailedFiles, entry.LocalPath)
+					config.Metrics.observeFile("failed")
+				default:
+					config.UploadedKeys = append(config.UploadedKeys, entry.Remote)
+					if info != nil {
+						state.markCompleted(entry.Name, info)
+					}
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	duration := time.Since(startTime)
+	interrupted := config.Interrupt.Stopped()
+	config.TotalFiles = int(atomic.LoadInt64(&source.found))
+
+	if interrupted {
+		fmt.Printf("\n⏸️  %s已中断\n", label)
+	} else {
+		fmt.Printf("\n🎯 %s完成！\n", label)
+	}
+	if source.err == errScanStopped {
+		fmt.Printf("已发现文件: %d 个 (扫描未完成)\n", config.TotalFiles)
+	} else {
+		fmt.Printf("总文件: %d 个\n", config.TotalFiles)
+	}
+	fmt.Printf("成功上传: %d 个\n", len(config.UploadedKeys))
+	if skipped > 0 {
+		fmt.Printf("续传跳过: %d 个\n", skipped)
+	}
+	if failed > 0 {
+		fmt.Printf("上传失败: %d 个\n", failed)
+	}
+	if config.Dedup != nil && config.Dedup.Copied > 0 {
+		fmt.Printf("去重复制: %d 个, 省去上传 %s\n", config.Dedup.Copied, humanSize(config.Dedup.BytesSaved))
+	}
+	if interrupted {
+		fmt.Printf("未完成: %d 个\n", config.TotalFiles-len(config.UploadedKeys)-skipped-failed)
+	}
+	fmt.Printf("总耗时: %.2f秒\n", duration.Seconds())
+	fmt.Printf("平均速度: %.2f 文件/秒\n", float64(len(config.UploadedKeys))/duration.Seconds())
+
+	if config.RemoteObject != "" {
+		fmt.Printf("\nOSS目录: %s\n", objectURL(config, config.RemoteObject))
+		if config.CDNBaseURL != "" {
+			fmt.Printf("CDN目录: %s\n", cdnURL(config, config.RemoteObject))
+		}
+	}
+
+	printPresignedURLs(config, bucket, config.UploadedKeys)
+
+	scanFailed := source.err != nil && source.err != errScanStopped
+	if !interrupted && !scanFailed && failed == 0 {
+		removeUploadState(config)
+		return nil
+	}
+
+	path, err := state.save(config)
+	if err != nil {
+		fmt.Printf("⚠️  保存续传状态失败: %v\n", err)
+	} else {
+		fmt.Printf("\n💾 续传状态已保存: %s\n", path)
+		fmt.Printf("💡 重新执行相同命令将只上传未完成的文件\n")
+	}
+	if interrupted {
+		return errInterrupted
+	}
+	if scanFailed {
+		return source.err
+	}
+	return &filesFailedError{Count: failed}
+}
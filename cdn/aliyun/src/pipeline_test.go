@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestWalkDirectory(t *testing.T) {
+	root := t.TempDir()
+	files := []string{"index.html", "js/app.js", "img/Logo.PNG", "img/icons/a.svg"}
+	for _, name := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		paths  pathMapper
+		want   []string
+	}{
+		{"prefix", "site/", pathMapper{}, []string{"site/img/Logo.PNG", "site/img/icons/a.svg", "site/index.html", "site/js/app.js"}},
+		{"bucket root", "", pathMapper{}, []string{"img/Logo.PNG", "img/icons/a.svg", "index.html", "js/app.js"}},
+		{"lowercase", "site/", pathMapper{Lowercase: true}, []string{"site/img/icons/a.svg", "site/img/logo.png", "site/index.html", "site/js/app.js"}},
+	}
+	for _, tt := range tests {
+		interrupt := &uploadInterrupt{stop: make(chan struct{})}
+		config := &UltraConfig{LocalPath: root, RemoteObject: tt.remote, Paths: tt.paths, Interrupt: interrupt}
+		source := walkDirectory(config)
+
+		var remotes []string
+		for entry := range source.entries {
+			if entry.LocalPath != filepath.Join(root, entry.Name) {
+				t.Errorf("%s: entry %s has local path %s", tt.name, entry.Name, entry.LocalPath)
+			}
+			remotes = append(remotes, entry.Remote)
+		}
+		sort.Strings(remotes)
+		if source.err != nil {
+			t.Errorf("%s: walkDirectory() error = %v", tt.name, source.err)
+		}
+		if !reflect.DeepEqual(remotes, tt.want) {
+			t.Errorf("%s: walkDirectory() = %q, want %q", tt.name, remotes, tt.want)
+		}
+		if got := source.total(); got != int64(len(files)) {
+			t.Errorf("%s: total() = %d, want %d", tt.name, got, len(files))
+		}
+	}
+}
+
+func TestWalkDirectoryErrors(t *testing.T) {
+	interrupt := &uploadInterrupt{stop: make(chan struct{})}
+	config := &UltraConfig{LocalPath: filepath.Join(t.TempDir(), "missing"), Interrupt: interrupt}
+	source := walkDirectory(config)
+	for range source.entries {
+		t.Errorf("walkDirectory() of a missing directory returned an entry")
+	}
+	if source.err == nil {
+		t.Errorf("walkDirectory() of a missing directory: want error")
+	}
+}
+
+func TestUploadEntriesPartialFailure(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	bucket := newTestEmulator(t)
+	root := t.TempDir()
+	good := filepath.Join(root, "good.txt")
+	if err := os.WriteFile(good, []byte("ok"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := &UltraConfig{
+		LocalPath:    root,
+		BucketName:   "bkt",
+		RemoteObject: "site/",
+		PartSize:     1024 * 1024,
+		IsDirectory:  true,
+		Interrupt:    &uploadInterrupt{stop: make(chan struct{})},
+	}
+	entries := []uploadEntry{
+		{Name: "good.txt", LocalPath: good, Remote: "site/good.txt"},
+		{Name: "missing.txt", LocalPath: filepath.Join(root, "missing.txt"), Remote: "site/missing.txt"},
+	}
+
+	// 有文件失败时返回错误，并保存续传状态
+	err := uploadEntries(config, bucket, sliceSource(entries), "目录上传")
+	var failedErr *filesFailedError
+	if !errors.As(err, &failedErr) || failedErr.Count != 1 {
+		t.Fatalf("uploadEntries() error = %v, want 1 failed file", err)
+	}
+	if !reflect.DeepEqual(config.UploadedKeys, []string{"site/good.txt"}) {
+		t.Errorf("UploadedKeys = %q, want [site/good.txt]", config.UploadedKeys)
+	}
+	if _, err := os.Stat(uploadStatePath(config)); err != nil {
+		t.Errorf("upload state not saved: %v", err)
+	}
+}
+
+func TestEntrySource(t *testing.T) {
+	entries := []uploadEntry{{Name: "a"}, {Name: "b"}}
+	source := sliceSource(entries)
+	if got := source.total(); got != 2 {
+		t.Errorf("sliceSource total() = %d, want 2", got)
+	}
+	var names []string
+	for entry := range source.entries {
+		names = append(names, entry.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("sliceSource entries = %q, want [a b]", names)
+	}
+
+	// 扫描未结束时总数未知
+	source = &entrySource{entries: make(chan uploadEntry, 1)}
+	interrupt := &uploadInterrupt{stop: make(chan struct{})}
+	if !source.push(interrupt, uploadEntry{Name: "a"}) {
+		t.Errorf("push() = false before interrupt")
+	}
+	if got := source.total(); got != -1 {
+		t.Errorf("total() while scanning = %d, want -1", got)
+	}
+
+	// 队列已满时中断不会一直阻塞
+	close(interrupt.stop)
+	if source.push(interrupt, uploadEntry{Name: "b"}) {
+		t.Errorf("push() = true after interrupt with a full queue")
+	}
+	source.finish(errScanStopped)
+	if got := source.total(); got != 1 {
+		t.Errorf("total() after finish = %d, want 1", got)
+	}
+}
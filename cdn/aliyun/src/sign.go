@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// SignConfig 预签名URL配置
+type SignConfig struct {
+	Keys        []string      // 对象路径
+	TTL         time.Duration // 有效期
+	Method      oss.HTTPMethod
+	ContentType string // PUT时客户端必须使用相同的Content-Type
+	// GET时覆盖响应头
+	ResponseContentType        string
+	ResponseContentDisposition string
+	ResponseCacheControl       string
+}
+
+const defaultPresignTTL = time.Hour
+
+func showSignUsage() {
+	fmt.Printf(`生成预签名URL - 无需凭证即可下载或上传
+
+用法: %s sign <远程路径> [远程路径...] [选项]
+
+选项:
+  -e, --ttl TTL                  有效期，如30m、12h、7d，默认1h
+  --put                          生成上传用的PUT URL (默认GET)
+  --content-type TYPE            PUT URL要求的Content-Type
+  --response-content-type TYPE   下载时覆盖Content-Type
+  --response-disposition VALUE   下载时覆盖Content-Disposition
+  --response-cache-control VALUE 下载时覆盖Cache-Control
+  -h                             帮助
+
+示例:
+  %s sign releases/v1.0/app.apk -e 7d
+  %s sign qa/report.zip --response-disposition "attachment; filename=report.zip"
+  %s sign uploads/log.tar.gz --put -e 2h
+  curl -T log.tar.gz "<PUT URL>"
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
+
+// 解析有效期，在time.ParseDuration基础上支持d(天)
+func parseTTL(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("无效的有效期: %s", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("无效的有效期: %s", value)
+	}
+	return ttl, nil
+}
+
+func parseSignConfig(args []string) (*SignConfig, error) {
+	config := &SignConfig{
+		TTL:    defaultPresignTTL,
+		Method: oss.HTTPGet,
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-e", "--ttl", "--content-type", "--response-content-type",
+			"--response-disposition", "--response-cache-control":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s 需要参数", arg)
+			}
+			value := args[i+1]
+			i++
+			switch arg {
+			case "-e", "--ttl":
+				ttl, err := parseTTL(value)
+				if err != nil {
+					return nil, err
+				}
+				config.TTL = ttl
+			case "--content-type":
+				config.ContentType = value
+			case "--response-content-type":
+				config.ResponseContentType = value
+			case "--response-disposition":
+				config.ResponseContentDisposition = value
+			case "--response-cache-control":
+				config.ResponseCacheControl = value
+			}
+		case "--put":
+			config.Method = oss.HTTPPut
+		case "-h", "--help":
+			showSignUsage()
+			os.Exit(0)
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return nil, fmt.Errorf("未知参数: %s", arg)
+			}
+			config.Keys = append(config.Keys, normalizeRemotePath(arg))
+		}
+	}
+
+	if len(config.Keys) == 0 {
+		return nil, fmt.Errorf("请指定要签名的远程路径")
+	}
+	if config.Method == oss.HTTPPut && (config.ResponseContentType != "" ||
+		config.ResponseContentDisposition != "" || config.ResponseCacheControl != "") {
+		return nil, fmt.Errorf("--response-* 选项只适用于GET URL")
+	}
+
+	return config, nil
+}
+
+func (c *SignConfig) options() []oss.Option {
+	var options []oss.Option
+	if c.ContentType != "" {
+		options = append(options, oss.ContentType(c.ContentType))
+	}
+	if c.ResponseContentType != "" {
+		options = append(options, oss.ResponseContentType(c.ResponseContentType))
+	}
+	if c.ResponseContentDisposition != "" {
+		options = append(options, oss.ResponseContentDisposition(c.ResponseContentDisposition))
+	}
+	if c.ResponseCacheControl != "" {
+		options = append(options, oss.ResponseCacheControl(c.ResponseCacheControl))
+	}
+	return options
+}
+
+func runSign(args []string) error {
+	signConfig, err := parseSignConfig(args)
+	if err != nil {
+		return err
+	}
+
+	config := &UltraConfig{Network: defaultNetworkConfig()}
+	if err := loadUltraOSSConfig(config); err != nil {
+		return err
+	}
+	bucket, err := newUltraBucket(config)
+	if err != nil {
+		return err
+	}
+
+	expires := time.Now().Add(signConfig.TTL)
+	fmt.Printf("🔏 预签名%s URL (有效期至 %s)\n", signConfig.Method, expires.Format("2006-01-02 15:04:05"))
+	if signConfig.Method == oss.HTTPPut && signConfig.ContentType != "" {
+		fmt.Printf("上传时必须携带请求头: Content-Type: %s\n", signConfig.ContentType)
+	}
+
+	for _, key := range signConfig.Keys {
+		signedURL, err := bucket.SignURL(key, signConfig.Method, int64(signConfig.TTL.Seconds()), signConfig.options()...)
+		if err != nil {
+			return fmt.Errorf("签名 %s 失败: %v", key, err)
+		}
+		fmt.Printf("\n%s\n%s\n", key, signedURL)
+	}
+
+	return nil
+}
+
+// 上传完成后为对象生成GET预签名URL
+func printPresignedURLs(config *UltraConfig, bucket *oss.Bucket, keys []string) {
+	if config.PresignTTL <= 0 || len(keys) == 0 {
+		return
+	}
+
+	expires := time.Now().Add(config.PresignTTL)
+	fmt.Printf("\n🔏 预签名下载地址 (有效期至 %s):\n", expires.Format("2006-01-02 15:04:05"))
+	for _, key := range keys {
+		signedURL, err := bucket.SignURL(key, oss.HTTPGet, int64(config.PresignTTL.Seconds()))
+		if err != nil {
+			fmt.Printf("  ❌ %s: %v\n", key, err)
+			continue
+		}
+		if len(keys) > 1 {
+			fmt.Printf("  %s\n", key)
+		}
+		fmt.Printf("  %s\n", signedURL)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"1h", time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"30s", 30 * time.Second, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"0d", 0, true},
+		{"-1d", 0, true},
+		{"d", 0, true},
+		{"1.5d", 0, true},
+		{"0s", 0, true},
+		{"-1h", 0, true},
+		{"10", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTTL(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTTL(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTTL(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseSignConfig(t *testing.T) {
+	tests := []struct {
+		args       []string
+		wantKeys   []string
+		wantTTL    time.Duration
+		wantMethod oss.HTTPMethod
+		wantErr    bool
+	}{
+		{[]string{"a.txt"}, []string{"a.txt"}, defaultPresignTTL, oss.HTTPGet, false},
+		{[]string{"-e", "2d", "a.txt", "b/c.txt"}, []string{"a.txt", "b/c.txt"}, 48 * time.Hour, oss.HTTPGet, false},
+		{[]string{"--put", "--content-type", "image/png", "a.png"}, []string{"a.png"}, defaultPresignTTL, oss.HTTPPut, false},
+		{[]string{"--response-disposition", "attachment", "a.txt"}, []string{"a.txt"}, defaultPresignTTL, oss.HTTPGet, false},
+		{[]string{"--put", "--response-content-type", "text/plain", "a.txt"}, nil, 0, "", true},
+		{[]string{"-e"}, nil, 0, "", true},
+		{[]string{"-e", "soon", "a.txt"}, nil, 0, "", true},
+		{[]string{"--bogus", "a.txt"}, nil, 0, "", true},
+		{nil, nil, 0, "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseSignConfig(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSignConfig(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(got.Keys, tt.wantKeys) || got.TTL != tt.wantTTL || got.Method != tt.wantMethod {
+			t.Errorf("parseSignConfig(%q) = %q %v %s, want %q %v %s", tt.args, got.Keys, got.TTL, got.Method, tt.wantKeys, tt.wantTTL, tt.wantMethod)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultGracePeriod = 30 * time.Second
+	exitInterrupted    = 130 // 收到中断信号后正常收尾退出
+	exitForceQuit      = 131 // 再次中断，强制退出
+)
+
+// 上传被中断，已收尾并保存续传状态
+var errInterrupted = errors.New("上传被中断")
+
+// 处理SIGINT/SIGTERM:
+// 第一次信号停止调度新文件，进行中的上传最多再等待grace，超时后取消所有请求；
+// 第二次信号立即强制退出
+type uploadInterrupt struct {
+	grace   time.Duration
+	stopped int32
+	ctx     context.Context
+	cancel  context.CancelFunc
+	signals chan os.Signal
+	stop    chan struct{} // 收到第一次信号时关闭
+	done    chan struct{}
+}
+
+func newUploadInterrupt(grace time.Duration) *uploadInterrupt {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := &uploadInterrupt{
+		grace:   grace,
+		ctx:     ctx,
+		cancel:  cancel,
+		signals: make(chan os.Signal, 2),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	signal.Notify(interrupt.signals, os.Interrupt, syscall.SIGTERM)
+	go interrupt.watch()
+	return interrupt
+}
+
+func (i *uploadInterrupt) watch() {
+	var sig os.Signal
+	select {
+	case sig = <-i.signals:
+	case <-i.done:
+		return
+	}
+	atomic.StoreInt32(&i.stopped, 1)
+	close(i.stop)
+	fmt.Printf("\n⏸️  收到 %v，不再开始新的上传，等待进行中的上传完成 (最多%s)\n", sig, i.grace)
+	fmt.Printf("   再按一次 Ctrl-C 强制退出\n")
+
+	timer := time.NewTimer(i.grace)
+	select {
+	case <-i.signals:
+		fmt.Printf("\n💥 强制退出\n")
+		os.Exit(exitForceQuit)
+	case <-timer.C:
+		fmt.Printf("\n⏹️  等待超时，取消进行中的请求\n")
+		i.cancel()
+	case <-i.done:
+		timer.Stop()
+		return
+	}
+
+	select {
+	case <-i.signals:
+		fmt.Printf("\n💥 强制退出\n")
+		os.Exit(exitForceQuit)
+	case <-i.done:
+	}
+}
+
+// 是否已收到中断信号
+func (i *uploadInterrupt) Stopped() bool {
+	return i != nil && atomic.LoadInt32(&i.stopped) == 1
+}
+
+// 等待d，期间收到中断信号时提前返回false
+func (i *uploadInterrupt) Sleep(d time.Duration) bool {
+	if i == nil {
+		time.Sleep(d)
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-i.stop:
+		return false
+	}
+}
+
+// 上传结束后恢复默认信号处理
+func (i *uploadInterrupt) Close() {
+	if i == nil {
+		return
+	}
+	signal.Stop(i.signals)
+	close(i.done)
+}
+
+// 为每个请求附加中断context，超时后正在传输的分片会被取消
+type interruptTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+func (t *interruptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	go func() {
+		select {
+		case <-t.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// 响应体读完关闭后再释放请求的context
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// 中断后的续传状态，重新执行相同命令时跳过已完成的文件
+type uploadState struct {
+	LocalPath     string                   `json:"local_path"`
+	Bucket        string                   `json:"bucket"`
+	RemoteObject  string                   `json:"remote_object"`
+	InterruptedAt time.Time                `json:"interrupted_at"`
+	Completed     map[string]completedFile `json:"completed"` // 相对路径 -> 文件信息
+}
+
+type completedFile struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// 续传状态和分片checkpoint的存放目录
+func stateDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "oss_ultra_fast")
+}
+
+func stateKey(parts ...string) string {
+	hash := md5.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// 单个对象分片上传的checkpoint文件
+func checkpointPath(config *UltraConfig, remoteObject string) string {
+	return filepath.Join(stateDir(), stateKey(config.Endpoint, config.BucketName, remoteObject)+".cp")
+}
+
+func uploadStatePath(config *UltraConfig) string {
+	localPath, err := filepath.Abs(config.LocalPath)
+	if err != nil {
+		localPath = config.LocalPath
+	}
+	return filepath.Join(stateDir(), stateKey(localPath, config.Endpoint, config.BucketName, config.RemoteObject)+".json")
+}
+
+// 读取上次中断留下的状态，不存在时返回空状态
+func loadUploadState(config *UltraConfig) *uploadState {
+	state := &uploadState{
+		LocalPath:    config.LocalPath,
+		Bucket:       config.BucketName,
+		RemoteObject: config.RemoteObject,
+		Completed:    map[string]completedFile{},
+	}
+
+	data, err := os.ReadFile(uploadStatePath(config))
+	if err != nil {
+		return state
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		fmt.Printf("⚠️  续传状态文件损坏，将重新上传: %v\n", err)
+		state.Completed = map[string]completedFile{}
+	}
+	if state.Completed == nil {
+		state.Completed = map[string]completedFile{}
+	}
+	return state
+}
+
+// 文件自上次上传后未变化时可跳过
+func (s *uploadState) isCompleted(relPath string, info os.FileInfo) bool {
+	done, ok := s.Completed[relPath]
+	return ok && done.Size == info.Size() && done.ModTime.Equal(info.ModTime())
+}
+
+func (s *uploadState) markCompleted(relPath string, info os.FileInfo) {
+	s.Completed[relPath] = completedFile{Size: info.Size(), ModTime: info.ModTime()}
+}
+
+func (s *uploadState) save(config *UltraConfig) (string, error) {
+	path := uploadStatePath(config)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	s.InterruptedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0644)
+}
+
+func removeUploadState(config *UltraConfig) {
+	os.Remove(uploadStatePath(config))
+}
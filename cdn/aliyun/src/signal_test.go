@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateKey(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		same bool
+	}{
+		{[]string{"ab", "c"}, []string{"ab", "c"}, true},
+		{[]string{"ab", "c"}, []string{"a", "bc"}, false},
+		{[]string{"a", ""}, []string{"a"}, false},
+		{[]string{"/data/build", "bkt", "releases/"}, []string{"/data/build", "bkt", "releases/v1/"}, false},
+	}
+	for _, tt := range tests {
+		if got := stateKey(tt.a...) == stateKey(tt.b...); got != tt.same {
+			t.Errorf("stateKey(%q) == stateKey(%q) is %v, want %v", tt.a, tt.b, got, tt.same)
+		}
+	}
+}
+
+func TestUploadState(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	write := func(name, content string) os.FileInfo {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+	config := &UltraConfig{LocalPath: root, Endpoint: "oss-cn-hangzhou.aliyuncs.com", BucketName: "bkt", RemoteObject: "site/"}
+
+	// 没有状态文件时从头开始
+	state := loadUploadState(config)
+	if len(state.Completed) != 0 {
+		t.Fatalf("loadUploadState() without a state file = %+v", state.Completed)
+	}
+
+	a := write("a.txt", "a")
+	b := write("b.txt", "b")
+	state.markCompleted("a.txt", a)
+	state.markCompleted("b.txt", b)
+	if _, err := state.save(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// b.txt 在中断后被修改
+	b = write("b.txt", "bb")
+	c := write("c.txt", "c")
+
+	loaded := loadUploadState(config)
+	tests := []struct {
+		name string
+		info os.FileInfo
+		want bool
+	}{
+		{"a.txt", a, true},
+		{"b.txt", b, false},
+		{"c.txt", c, false},
+	}
+	for _, tt := range tests {
+		if got := loaded.isCompleted(tt.name, tt.info); got != tt.want {
+			t.Errorf("isCompleted(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// 不同的目标使用不同的状态文件
+	other := *config
+	other.RemoteObject = "site-v2/"
+	if got := loadUploadState(&other); len(got.Completed) != 0 {
+		t.Errorf("state for another remote prefix = %+v, want empty", got.Completed)
+	}
+
+	// 状态文件损坏时重新上传
+	if err := os.WriteFile(uploadStatePath(config), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadUploadState(config); got.Completed == nil || len(got.Completed) != 0 {
+		t.Errorf("corrupted state = %+v, want empty", got.Completed)
+	}
+
+	removeUploadState(config)
+	if _, err := os.Stat(uploadStatePath(config)); !os.IsNotExist(err) {
+		t.Errorf("removeUploadState() left the state file")
+	}
+}
+
+func TestUploadInterruptSleep(t *testing.T) {
+	var disabled *uploadInterrupt
+	if disabled.Stopped() || !disabled.Sleep(time.Millisecond) {
+		t.Errorf("nil interrupt should never stop")
+	}
+
+	interrupt := &uploadInterrupt{stop: make(chan struct{})}
+	if !interrupt.Sleep(time.Millisecond) {
+		t.Errorf("Sleep() = false without an interrupt")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(interrupt.stop)
+	}()
+	start := time.Now()
+	if interrupt.Sleep(time.Minute) {
+		t.Errorf("Sleep() = true after an interrupt")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Sleep() returned after %v, want right after the interrupt", elapsed)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const fileHashLength = 8 // {file.hash} 取SHA-256的前8位
+
+// 远程路径中的模板变量，如 {date}、{git.sha}、{env.BUILD_ID}
+var templatePattern = regexp.MustCompile(`\{([a-z]+(?:\.[A-Za-z_][A-Za-z0-9_]*)?)\}`)
+
+// 远程路径模板，运行级变量每次运行只取一次值，{file.*} 按文件展开
+type keyTemplate struct {
+	now    time.Time
+	dir    string            // 查找git仓库和VERSION文件的起点
+	values map[string]string // 已取值的运行级变量
+	used   []string          // 展开过的变量，用于显示
+}
+
+func newKeyTemplate(localPath string) *keyTemplate {
+	dir := localPath
+	if info, err := os.Stat(localPath); err != nil || !info.IsDir() {
+		dir = filepath.Dir(localPath)
+	}
+	return &keyTemplate{now: time.Now(), dir: dir, values: map[string]string{}}
+}
+
+func hasTemplate(value string) bool {
+	return templatePattern.MatchString(value)
+}
+
+func hasFileTemplate(value string) bool {
+	for _, match := range templatePattern.FindAllStringSubmatch(value, -1) {
+		if strings.HasPrefix(match[1], "file.") {
+			return true
+		}
+	}
+	return false
+}
+
+// 展开运行级变量，{file.*} 保留到上传每个文件时再展开
+func (t *keyTemplate) expand(value string) (string, error) {
+	var expandErr error
+	result := templatePattern.ReplaceAllStringFunc(value, func(match string) string {
+		name := match[1 : len(match)-1]
+		if strings.HasPrefix(name, "file.") {
+			if name != "file.hash" {
+				expandErr = fmt.Errorf("未知的模板变量 %s", match)
+			}
+			return match
+		}
+		v, err := t.lookup(name)
+		if err != nil && expandErr == nil {
+			expandErr = err
+		}
+		return v
+	})
+	return result, expandErr
+}
+
+func (t *keyTemplate) lookup(name string) (string, error) {
+	if v, ok := t.values[name]; ok {
+		return v, nil
+	}
+
+	var v string
+	var err error
+	switch {
+	case name == "date":
+		v = t.now.Format("2006-01-02")
+	case name == "time":
+		v = t.now.Format("150405")
+	case name == "git.sha":
+		v, err = gitOutput(t.dir, "rev-parse", "--short", "HEAD")
+	case name == "git.branch":
+		v, err = gitBranch(t.dir)
+	case name == "version":
+		v, err = findVersion(t.dir)
+	case strings.HasPrefix(name, "env."):
+		envName := strings.TrimPrefix(name, "env.")
+		if v = os.Getenv(envName); v == "" {
+			err = fmt.Errorf("环境变量 %s 未设置", envName)
+		}
+	default:
+		return "", fmt.Errorf("未知的模板变量 {%s}", name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("模板变量 {%s}: %v", name, err)
+	}
+
+	t.values[name] = v
+	t.used = append(t.used, name)
+	return v, nil
+}
+
+// 展开 {file.hash}
+func (t *keyTemplate) expandFile(value, localFile string) (string, error) {
+	if !strings.Contains(value, "{file.hash}") {
+		return value, nil
+	}
+	hash, err := fileHash(localFile)
+	if err != nil {
+		return "", fmt.Errorf("模板变量 {file.hash}: %v", err)
+	}
+	return strings.ReplaceAll(value, "{file.hash}", hash), nil
+}
+
+func fileHash(localFile string) (string, error) {
+	file, err := os.Open(localFile)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:fileHashLength], nil
+}
+
+// 显示模板和各变量的值
+func (t *keyTemplate) print(template string) {
+	if t == nil || !hasTemplate(template) {
+		return
+	}
+	fmt.Printf("🏷️  远程路径模板: %s\n", template)
+	for _, name := range t.used {
+		fmt.Printf("   {%s} = %s\n", name, t.values[name])
+	}
+	if hasFileTemplate(template) {
+		fmt.Printf("   {file.hash} = 每个文件内容SHA-256的前%d位\n", fileHashLength)
+	}
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// 当前分支，分支名中的/替换为-；CI中常见的detached HEAD改用CI提供的分支名
+func gitBranch(dir string) (string, error) {
+	branch, err := gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if branch == "HEAD" {
+		branch = ""
+		for _, name := range []string{"GITHUB_REF_NAME", "CI_COMMIT_REF_NAME", "BRANCH_NAME", "GIT_BRANCH"} {
+			if branch = os.Getenv(name); branch != "" {
+				break
+			}
+		}
+		if branch == "" {
+			return "", fmt.Errorf("当前不在任何分支上 (detached HEAD)")
+		}
+	}
+	return strings.ReplaceAll(branch, "/", "-"), nil
+}
+
+// 从本地路径所在目录向上查找VERSION文件，找不到时再查找当前目录
+func findVersion(dir string) (string, error) {
+	dirs := []string{}
+	if abs, err := filepath.Abs(dir); err == nil {
+		for {
+			dirs = append(dirs, abs)
+			parent := filepath.Dir(abs)
+			if parent == abs {
+				break
+			}
+			abs = parent
+		}
+	}
+	dirs = append(dirs, ".")
+
+	for _, d := range dirs {
+		data, err := os.ReadFile(filepath.Join(d, "VERSION"))
+		if err != nil {
+			continue
+		}
+		lines := strings.SplitN(string(data), "\n", 2)
+		if version := strings.TrimSpace(lines[0]); version != "" {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("找不到VERSION文件")
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKeyTemplateExpand(t *testing.T) {
+	t.Setenv("BUILD_ID", "1234")
+	t.Setenv("EMPTY_VAR", "")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "VERSION"), []byte(" 1.2.3 \nchangelog\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 3, 5, 8, 9, 10, 0, time.Local)
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"releases/app.apk", "releases/app.apk", false},
+		{"releases/{date}/", "releases/2024-03-05/", false},
+		{"builds/{date}-{time}/", "builds/2024-03-05-080910/", false},
+		{"builds/{env.BUILD_ID}/app.apk", "builds/1234/app.apk", false},
+		{"releases/{version}/", "releases/1.2.3/", false},
+		{"static/{file.hash}/", "static/{file.hash}/", false}, // 上传每个文件时再展开
+		{"{DATE}/a", "{DATE}/a", false},                       // 不是模板变量，按原样保留
+		{"static/{file.size}/", "", true},
+		{"builds/{env.EMPTY_VAR}/", "", true},
+		{"builds/{build}/", "", true},
+	}
+	for _, tt := range tests {
+		tmpl := newKeyTemplate(dir)
+		tmpl.now = now
+		got, err := tmpl.expand(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("expand(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("expand(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestKeyTemplateValuesOncePerRun(t *testing.T) {
+	t.Setenv("BUILD_ID", "1")
+	tmpl := newKeyTemplate(t.TempDir())
+	first, err := tmpl.expand("{env.BUILD_ID}/{date}/{env.BUILD_ID}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 运行期间变量的值不变
+	os.Setenv("BUILD_ID", "2")
+	second, err := tmpl.expand("{env.BUILD_ID}/{date}/{env.BUILD_ID}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("expand() = %q then %q, want the same value", first, second)
+	}
+	if want := []string{"env.BUILD_ID", "date"}; !reflect.DeepEqual(tmpl.used, want) {
+		t.Errorf("used = %q, want %q", tmpl.used, want)
+	}
+}
+
+func TestKeyTemplateExpandFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.js")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl := newKeyTemplate(dir)
+
+	tests := []struct {
+		value   string
+		file    string
+		want    string
+		wantErr bool
+	}{
+		// sha256("hello") = 2cf24dba...
+		{"static/{file.hash}/app.js", path, "static/2cf24dba/app.js", false},
+		{"static/app.{file.hash}.js", path, "static/app.2cf24dba.js", false},
+		{"static/app.js", filepath.Join(dir, "missing"), "static/app.js", false},
+		{"static/{file.hash}/app.js", filepath.Join(dir, "missing"), "", true},
+	}
+	for _, tt := range tests {
+		got, err := tmpl.expandFile(tt.value, tt.file)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("expandFile(%q, %q) error = %v, wantErr %v", tt.value, tt.file, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expandFile(%q, %q) = %q, want %q", tt.value, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestHasTemplate(t *testing.T) {
+	tests := []struct {
+		value    string
+		want     bool
+		wantFile bool
+	}{
+		{"releases/app.apk", false, false},
+		{"releases/{date}/", true, false},
+		{"static/{file.hash}/", true, true},
+		{"{env.CI}/{file.hash}", true, true},
+		{"{}/a", false, false},
+		{"{Date}/a", false, false},
+	}
+	for _, tt := range tests {
+		if got := hasTemplate(tt.value); got != tt.want {
+			t.Errorf("hasTemplate(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+		if got := hasFileTemplate(tt.value); got != tt.wantFile {
+			t.Errorf("hasFileTemplate(%q) = %v, want %v", tt.value, got, tt.wantFile)
+		}
+	}
+}
+
+func TestFindVersion(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "dist", "assets")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := findVersion(nested); err == nil {
+		t.Errorf("findVersion() without VERSION: want error")
+	}
+
+	// 从子目录向上找到VERSION
+	if err := os.WriteFile(filepath.Join(root, "VERSION"), []byte("v2.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := findVersion(nested); err != nil || got != "v2.0.0" {
+		t.Errorf("findVersion() = %q, %v, want v2.0.0", got, err)
+	}
+
+	// 空的VERSION跳过，继续向上查找
+	if err := os.WriteFile(filepath.Join(root, "dist", "VERSION"), []byte("\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := findVersion(nested); err != nil || got != "v2.0.0" {
+		t.Errorf("findVersion() with an empty VERSION = %q, %v, want v2.0.0", got, err)
+	}
+}
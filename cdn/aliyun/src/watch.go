@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+const (
+	defaultDebounce     = 500 * time.Millisecond
+	maxDebounce         = 5 * time.Second // 持续变化时最多等待这么久就同步一次
+	defaultPollInterval = 2 * time.Second
+)
+
+// 文件变化通知，只表示"有变化"，具体变化由重新扫描目录得出
+type changeNotifier interface {
+	Changes() <-chan struct{}
+	Close() error
+}
+
+// 文件在扫描时的状态
+type fileStamp struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// 扫描目录，返回相对路径到文件状态的映射
+func scanTree(root string) (map[string]fileStamp, error) {
+	tree := map[string]fileStamp{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// 扫描期间被删除的文件忽略
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		tree[relPath] = fileStamp{Size: info.Size(), ModTime: info.ModTime()}
+		return nil
+	})
+	return tree, err
+}
+
+// 比较两次扫描结果
+func diffTree(before, after map[string]fileStamp) (changed, deleted []string) {
+	for relPath, stamp := range after {
+		if old, ok := before[relPath]; !ok || old.Size != stamp.Size || !old.ModTime.Equal(stamp.ModTime) {
+			changed = append(changed, relPath)
+		}
+	}
+	for relPath := range before {
+		if _, ok := after[relPath]; !ok {
+			deleted = append(deleted, relPath)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted
+}
+
+// 轮询通知，无法使用系统通知时的后备方案
+type pollNotifier struct {
+	changes chan struct{}
+	done    chan struct{}
+}
+
+func newPollNotifier(interval time.Duration) *pollNotifier {
+	notifier := &pollNotifier{changes: make(chan struct{}, 1), done: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case notifier.changes <- struct{}{}:
+				default:
+				}
+			case <-notifier.done:
+				return
+			}
+		}
+	}()
+	return notifier
+}
+
+func (p *pollNotifier) Changes() <-chan struct{} { return p.changes }
+
+func (p *pollNotifier) Close() error {
+	close(p.done)
+	return nil
+}
+
+// 目录上传完成后持续监听变化，同步新增、修改和删除的文件，直到Ctrl-C
+func watchDirectory(config *UltraConfig, bucket *oss.Bucket, synced map[string]fileStamp) error {
+	notifier, err := newNotifyWatcher(config.LocalPath)
+	mode := "inotify"
+	if err != nil {
+		fmt.Printf("⚠️  无法使用系统文件通知 (%v)，改为每%s轮询\n", err, defaultPollInterval)
+		notifier = newPollNotifier(defaultPollInterval)
+		mode = "轮询"
+	}
+	defer notifier.Close()
+
+	fmt.Printf("\n👀 开始监听 %s (%s)，按 Ctrl-C 退出\n", config.LocalPath, mode)
+
+	// 首次上传失败的文件不算已同步，第一轮就会重新上传
+	for _, localPath := range config.FailedFiles {
+		if relPath, err := filepath.Rel(config.LocalPath, localPath); err == nil {
+			delete(synced, relPath)
+		}
+	}
+
+	uploaded, deletedCount, failed := 0, 0, 0
+	lastSync := time.Now()
+	printStatus := func() {
+		fmt.Printf("\r👀 监听中 | 上传 %d | 删除 %d | 失败 %d | 最后同步 %s ",
+			uploaded, deletedCount, failed, lastSync.Format("15:04:05"))
+	}
+
+	// 首次同步期间发生的变化立即处理
+	pending := true
+	for {
+		if !pending {
+			printStatus()
+			select {
+			case <-notifier.Changes():
+			case <-config.Interrupt.stop:
+			}
+			if config.Interrupt.Stopped() {
+				break
+			}
+			waitQuiet(notifier.Changes(), config.Debounce, config.Interrupt)
+		}
+		pending = false
+		if config.Interrupt.Stopped() {
+			break
+		}
+
+		current, err := scanTree(config.LocalPath)
+		if err != nil {
+			fmt.Printf("\n⚠️  扫描目录失败: %v\n", err)
+			continue
+		}
+		changed, deleted := diffTree(synced, current)
+		if len(changed) == 0 && len(deleted) == 0 {
+			continue
+		}
+		fmt.Printf("\n")
+
+		var keys []string
+		for _, relPath := range changed {
+			if config.Interrupt.Stopped() {
+				break
+			}
+			remotePath, err := directoryRemoteKey(config, relPath)
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				failed++
+				continue
+			}
+			fmt.Printf("📤 %s\n", relPath)
+			err = uploadSingleFile(config, bucket, filepath.Join(config.LocalPath, relPath), remotePath)
+			if errors.Is(err, errInterrupted) {
+				break
+			}
+			if err != nil {
+				// 保留旧状态，下次变化时重试
+				fmt.Printf("❌ 上传失败 %s: %v\n", relPath, err)
+				config.Metrics.observeFile("failed")
+				failed++
+				continue
+			}
+			synced[relPath] = current[relPath]
+			keys = append(keys, remotePath)
+			uploaded++
+		}
+
+		for _, relPath := range deleted {
+			if config.Interrupt.Stopped() {
+				break
+			}
+			remotePath, err := directoryRemoteKey(config, relPath)
+			if err != nil {
+				// 对象名依赖文件内容 ({file.hash}) 时无法确定已删除文件的对象名
+				fmt.Printf("⚠️  跳过删除 %s: %v\n", relPath, err)
+				delete(synced, relPath)
+				continue
+			}
+			err = withRetry(config, "删除 "+remotePath, func() error {
+				return bucket.DeleteObject(remotePath)
+			})
+			config.History.observeFile(historyFile{Key: remotePath, Size: synced[relPath].Size, Result: "deleted"}, err)
+			if err != nil {
+				fmt.Printf("❌ 删除失败 %s: %v\n", relPath, err)
+				failed++
+				continue
+			}
+			fmt.Printf("🗑️  %s\n", relPath)
+			delete(synced, relPath)
+			keys = append(keys, remotePath)
+			deletedCount++
+		}
+		lastSync = time.Now()
+		config.History.flush("watch", nil)
+
+		config.UploadedKeys = keys
+		if err := refreshCDNAfterUpload(config); err != nil {
+			fmt.Printf("❌ %v\n", err)
+		}
+	}
+
+	fmt.Printf("\n\n⏹️  停止监听\n")
+	fmt.Printf("监听期间上传: %d 个, 删除: %d 个, 失败: %d 个\n", uploaded, deletedCount, failed)
+	return nil
+}
+
+// 等待变化平息: debounce内没有新的变化，或累计等待超过maxDebounce
+func waitQuiet(changes <-chan struct{}, debounce time.Duration, interrupt *uploadInterrupt) {
+	deadline := time.NewTimer(maxDebounce)
+	defer deadline.Stop()
+	quiet := time.NewTimer(debounce)
+	defer quiet.Stop()
+
+	for {
+		select {
+		case <-changes:
+			if !quiet.Stop() {
+				<-quiet.C
+			}
+			quiet.Reset(debounce)
+		case <-quiet.C:
+			return
+		case <-deadline.C:
+			return
+		case <-interrupt.stop:
+			return
+		}
+	}
+}
+
+// 目录模式下本地相对路径对应的对象名
+func directoryRemoteKey(config *UltraConfig, relPath string) (string, error) {
+	prefix, err := config.Template.expandFile(config.RemoteObject, filepath.Join(config.LocalPath, relPath))
+	if err != nil {
+		return "", err
+	}
+	return joinObjectKey(prefix, config.Paths.mapRelative(relPath))
+}
@@ -0,0 +1,116 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_ATTRIB |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF
+
+// 基于inotify的目录监听，子目录逐个添加watch
+type inotifyNotifier struct {
+	file    *os.File
+	fd      int
+	changes chan struct{}
+	mutex   sync.Mutex
+	dirs    map[int32]string // watch描述符 -> 目录
+}
+
+func newNotifyWatcher(root string) (changeNotifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier := &inotifyNotifier{
+		// 非阻塞fd交给runtime poller，Close时可中断Read
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		fd:      fd,
+		changes: make(chan struct{}, 1),
+		dirs:    map[int32]string{},
+	}
+	if err := notifier.addTree(root); err != nil {
+		notifier.file.Close()
+		return nil, err
+	}
+
+	go notifier.readEvents()
+	return notifier, nil
+}
+
+// 为目录及其所有子目录添加watch
+func (n *inotifyNotifier) addTree(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		wd, err := syscall.InotifyAddWatch(n.fd, path, inotifyMask)
+		if err != nil {
+			// ENOSPC: 超过fs.inotify.max_user_watches
+			return err
+		}
+		n.mutex.Lock()
+		n.dirs[int32(wd)] = path
+		n.mutex.Unlock()
+		return nil
+	})
+}
+
+func (n *inotifyNotifier) readEvents() {
+	buf := make([]byte, 64*1024)
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= count; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			// 新建或移入的子目录需要继续监听
+			if event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				n.mutex.Lock()
+				dir := n.dirs[event.Wd]
+				n.mutex.Unlock()
+				name := string(nameBytes)
+				if i := strings.IndexByte(name, 0); i >= 0 {
+					name = name[:i]
+				}
+				if dir != "" && name != "" {
+					n.addTree(filepath.Join(dir, name))
+				}
+			}
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				n.mutex.Lock()
+				delete(n.dirs, event.Wd)
+				n.mutex.Unlock()
+			}
+		}
+
+		select {
+		case n.changes <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (n *inotifyNotifier) Changes() <-chan struct{} { return n.changes }
+
+func (n *inotifyNotifier) Close() error {
+	return n.file.Close()
+}
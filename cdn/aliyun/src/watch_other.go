@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// 非Linux平台暂不支持系统文件通知，使用轮询
+func newNotifyWatcher(root string) (changeNotifier, error) {
+	return nil, errors.New("当前平台不支持inotify")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffTree(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := map[string]fileStamp{
+		"a.js":      {Size: 10, ModTime: t0},
+		"b.js":      {Size: 10, ModTime: t0},
+		"c.js":      {Size: 10, ModTime: t0},
+		"css/d.css": {Size: 10, ModTime: t0},
+	}
+	tests := []struct {
+		name        string
+		before      map[string]fileStamp
+		after       map[string]fileStamp
+		wantChanged []string
+		wantDeleted []string
+	}{
+		{"unchanged", before, before, nil, nil},
+		{"initial scan", nil, before, []string{"a.js", "b.js", "c.js", "css/d.css"}, nil},
+		{
+			name:   "mixed",
+			before: before,
+			after: map[string]fileStamp{
+				"a.js":      {Size: 11, ModTime: t0},                  // 大小变化
+				"b.js":      {Size: 10, ModTime: t0.Add(time.Second)}, // 修改时间变化
+				"css/d.css": {Size: 10, ModTime: t0.In(time.Local)},   // 同一时刻，不算变化
+				"e.js":      {Size: 1, ModTime: t0},                   // 新文件
+			},
+			wantChanged: []string{"a.js", "b.js", "e.js"},
+			wantDeleted: []string{"c.js"},
+		},
+		{"all deleted", before, map[string]fileStamp{}, nil, []string{"a.js", "b.js", "c.js", "css/d.css"}},
+	}
+	for _, tt := range tests {
+		changed, deleted := diffTree(tt.before, tt.after)
+		if !reflect.DeepEqual(changed, tt.wantChanged) || !reflect.DeepEqual(deleted, tt.wantDeleted) {
+			t.Errorf("%s: diffTree() = %q, %q, want %q, %q", tt.name, changed, deleted, tt.wantChanged, tt.wantDeleted)
+		}
+	}
+}
+
+func TestScanTree(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{"a.js": "abc", "css/b.css": "", "css/img/c.png": "12345"}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := scanTree(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree) != len(files) {
+		t.Errorf("scanTree() found %d files, want %d: %v", len(tree), len(files), tree)
+	}
+	for name, content := range files {
+		stamp, ok := tree[filepath.FromSlash(name)]
+		if !ok {
+			t.Errorf("scanTree() missing %s", name)
+			continue
+		}
+		if stamp.Size != int64(len(content)) {
+			t.Errorf("scanTree() size of %s = %d, want %d", name, stamp.Size, len(content))
+		}
+	}
+}